@@ -0,0 +1,105 @@
+// Package akamaitest provides utilities for testing code that uses the akamai package.
+package akamaitest
+
+import (
+	"context"
+	"errors"
+
+	akamai "github.com/SolarSystems-Software/akamai-sdk-go"
+)
+
+// ErrNotStubbed is returned by MockSession methods whose stub function is nil.
+var ErrNotStubbed = errors.New("akamai-sdk-go/akamaitest: method not stubbed")
+
+// MockSession is an akamai.Generator that calls the stub function of each method instead of the
+// SolarSystems API. Methods whose stub function is nil return ErrNotStubbed.
+//
+// MockSession is safe for usage by multiple goroutines if its stub functions are.
+type MockSession struct {
+	GenerateFunc func(
+		ctx context.Context,
+		userAgent,
+		pageUrl string,
+		doHttpReq akamai.DoHttpReqFunc,
+		getCookie akamai.GetCookieFunc,
+		maxTries int,
+	) error
+
+	GenerateWithResultFunc func(
+		ctx context.Context,
+		userAgent,
+		pageUrl string,
+		doHttpReq akamai.DoHttpReqFunc,
+		getCookie akamai.GetCookieFunc,
+		maxTries int,
+	) (*akamai.GenerateResult, error)
+
+	GenerateSensorDataFunc func(ctx context.Context, req *akamai.GenerateRequest) (*akamai.GenerateResponse, error)
+
+	GeneratePixelPayloadFunc func(ctx context.Context, req *akamai.PixelSolveRequest) (*akamai.PixelSolveResponse, error)
+
+	GenerateSecCptPayloadFunc func(
+		ctx context.Context,
+		req *akamai.SecCptSolveRequest,
+	) (*akamai.SecCptSolveResponse, error)
+}
+
+var _ akamai.Generator = (*MockSession)(nil)
+
+func (m *MockSession) Generate(
+	ctx context.Context,
+	userAgent,
+	pageUrl string,
+	doHttpReq akamai.DoHttpReqFunc,
+	getCookie akamai.GetCookieFunc,
+	maxTries int,
+) error {
+	if m.GenerateFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.GenerateFunc(ctx, userAgent, pageUrl, doHttpReq, getCookie, maxTries)
+}
+
+func (m *MockSession) GenerateWithResult(
+	ctx context.Context,
+	userAgent,
+	pageUrl string,
+	doHttpReq akamai.DoHttpReqFunc,
+	getCookie akamai.GetCookieFunc,
+	maxTries int,
+) (*akamai.GenerateResult, error) {
+	if m.GenerateWithResultFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return m.GenerateWithResultFunc(ctx, userAgent, pageUrl, doHttpReq, getCookie, maxTries)
+}
+
+func (m *MockSession) GenerateSensorData(
+	ctx context.Context,
+	req *akamai.GenerateRequest,
+) (*akamai.GenerateResponse, error) {
+	if m.GenerateSensorDataFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return m.GenerateSensorDataFunc(ctx, req)
+}
+
+func (m *MockSession) GeneratePixelPayload(
+	ctx context.Context,
+	req *akamai.PixelSolveRequest,
+) (*akamai.PixelSolveResponse, error) {
+	if m.GeneratePixelPayloadFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return m.GeneratePixelPayloadFunc(ctx, req)
+}
+
+func (m *MockSession) GenerateSecCptPayload(
+	ctx context.Context,
+	req *akamai.SecCptSolveRequest,
+) (*akamai.SecCptSolveResponse, error) {
+	if m.GenerateSecCptPayloadFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return m.GenerateSecCptPayloadFunc(ctx, req)
+}
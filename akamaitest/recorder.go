@@ -0,0 +1,141 @@
+package akamaitest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	akamai "github.com/SolarSystems-Software/akamai-sdk-go"
+)
+
+// RecordedRequest is a request made with Recorder.DoHttpReq.
+type RecordedRequest struct {
+	Op      akamai.HttpReqOp
+	URL     string
+	Method  string
+	BodyLen int
+}
+
+// CannedResponse is a response returned by Recorder.DoHttpReq.
+type CannedResponse struct {
+	// StatusCode is the HTTP status code. If zero, 200 is used.
+	StatusCode int
+
+	// Body is the response body. It is returned as is, so it must not be modified by callers of DoHttpReq.
+	Body []byte
+
+	// Err is the error to return instead of a response, e.g. to simulate network failures.
+	Err error
+}
+
+// emptyBody is the body of responses without a body, as a DoHttpReqFunc must not return a nil body.
+var emptyBody = []byte{}
+
+// Recorder is a fake website whose DoHttpReq method is an akamai.DoHttpReqFunc that records each request
+// and returns canned responses, to test the requests made by akamai.Session.Generate and custom flows:
+//
+//	recorder := &akamaitest.Recorder{Responses: map[akamai.HttpReqOp][]akamaitest.CannedResponse{
+//		akamai.OpGetPage: {{Body: page}},
+//	}}
+//	err := session.Generate(ctx, userAgent, pageUrl, recorder.DoHttpReq, getCookie, 1)
+//
+// Recording does not allocate once the request log has grown to its final size, so Recorder can be used in
+// benchmarks; see Reset. The zero value responds with 200 and an empty body to every request.
+//
+// Recorder is safe for usage by multiple goroutines. Responses must not be modified while it is in use.
+type Recorder struct {
+	// Responses are the responses to return for each operation, in order. Once the responses of an operation
+	// are exhausted, the last one is repeated. Operations without responses get 200 and an empty body.
+	Responses map[akamai.HttpReqOp][]CannedResponse
+
+	mu       sync.Mutex
+	requests []RecordedRequest
+	served   map[akamai.HttpReqOp]int
+}
+
+// DoHttpReq implements akamai.DoHttpReqFunc. The request body is read to record its length.
+func (r *Recorder) DoHttpReq(
+	_ context.Context,
+	op akamai.HttpReqOp,
+	requestUrl,
+	requestMethod string,
+	requestBody io.Reader,
+) (statusCode int, responseBody []byte, err error) {
+	bodyLen, err := readBodyLen(requestBody)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	r.mu.Lock()
+	r.requests = append(r.requests, RecordedRequest{Op: op, URL: requestUrl, Method: requestMethod, BodyLen: bodyLen})
+	response := CannedResponse{StatusCode: http.StatusOK}
+	if responses := r.Responses[op]; len(responses) > 0 {
+		if r.served == nil {
+			r.served = make(map[akamai.HttpReqOp]int)
+		}
+		i := r.served[op]
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		r.served[op]++
+		response = responses[i]
+	}
+	r.mu.Unlock()
+
+	if response.Err != nil {
+		return 0, nil, response.Err
+	}
+	if response.StatusCode == 0 {
+		response.StatusCode = http.StatusOK
+	}
+	if response.Body == nil {
+		response.Body = emptyBody
+	}
+	return response.StatusCode, response.Body, nil
+}
+
+// readBodyLen returns the length of body, which may be nil, reading it only if it does not report its length.
+func readBodyLen(body io.Reader) (int, error) {
+	switch body := body.(type) {
+	case nil:
+		return 0, nil
+	case interface{ Len() int }:
+		return body.Len(), nil
+	default:
+		n, err := io.Copy(io.Discard, body)
+		return int(n), err
+	}
+}
+
+// Ops returns the operations of the recorded requests, in order.
+func (r *Recorder) Ops() []akamai.HttpReqOp {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ops := make([]akamai.HttpReqOp, len(r.requests))
+	for i, request := range r.requests {
+		ops[i] = request.Op
+	}
+	return ops
+}
+
+// Requests returns a copy of the recorded requests, in order.
+func (r *Recorder) Requests() []RecordedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]RecordedRequest(nil), r.requests...)
+}
+
+// Reset clears the recorded requests and restarts the responses of each operation from the first one.
+// The memory of the request log is kept for reuse.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = r.requests[:0]
+	for op := range r.served {
+		delete(r.served, op)
+	}
+}
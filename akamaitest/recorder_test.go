@@ -0,0 +1,83 @@
+package akamaitest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	akamai "github.com/SolarSystems-Software/akamai-sdk-go"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	defer server.Close()
+
+	recorder := &Recorder{Responses: map[akamai.HttpReqOp][]CannedResponse{
+		akamai.OpGetPage:      {{Body: []byte(`<html><script src="/aBc-dEf/gHi"></script></html>`)}},
+		akamai.OpGetSdkScript: {{Body: []byte(`var _acxj=[];`)}},
+	}}
+	session := akamai.NewSessionWithOptions("key", akamai.WithBaseURL(server.URL))
+	getCookie := func(*url.URL, string) string { return "" }
+	if err := session.Generate(context.Background(), testUserAgent, "https://www.example.com/product", recorder.DoHttpReq, getCookie, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []akamai.HttpReqOp{akamai.OpGetPage, akamai.OpGetSdkScript, akamai.OpPostSensorData}
+	if v := recorder.Ops(); !reflect.DeepEqual(v, expected) {
+		t.Fatal("unexpected ops:", v)
+	}
+	requests := recorder.Requests()
+	if v := requests[2]; v.URL != "https://www.example.com/aBc-dEf/gHi" || v.Method != http.MethodPost ||
+		v.BodyLen != len(akamai.SensorDataEnvelope("payload")) {
+		t.Fatalf("unexpected sensor data request: %+v", v)
+	}
+
+	// Responses are served in order, repeating the last one
+	errNetwork := errors.New("network error")
+	recorder = &Recorder{Responses: map[akamai.HttpReqOp][]CannedResponse{
+		akamai.OpGetPage: {{Err: errNetwork}, {StatusCode: http.StatusForbidden}},
+	}}
+	if _, _, err := recorder.DoHttpReq(context.Background(), akamai.OpGetPage, "https://www.example.com", http.MethodGet, nil); err != errNetwork {
+		t.Fatal("expected network error, got:", err)
+	}
+	for i := 0; i < 2; i++ {
+		statusCode, body, err := recorder.DoHttpReq(context.Background(), akamai.OpGetPage, "https://www.example.com", http.MethodGet, nil)
+		if err != nil || statusCode != http.StatusForbidden || body == nil {
+			t.Fatal("unexpected response:", statusCode, body, err)
+		}
+	}
+
+	recorder.Reset()
+	if v := recorder.Requests(); len(v) != 0 {
+		t.Fatal("unexpected requests after Reset:", v)
+	}
+	if _, _, err := recorder.DoHttpReq(context.Background(), akamai.OpGetPage, "https://www.example.com", http.MethodGet, nil); err != errNetwork {
+		t.Fatal("expected network error after Reset, got:", err)
+	}
+}
+
+func TestRecorderAllocs(t *testing.T) {
+	recorder := &Recorder{}
+	payload := make([]byte, 512)
+	body := bytes.NewReader(payload)
+	for i := 0; i < 100; i++ {
+		_, _, _ = recorder.DoHttpReq(context.Background(), akamai.OpPostSensorData, "https://www.example.com", http.MethodPost, body)
+	}
+	recorder.Reset()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		body.Reset(payload)
+		_, _, _ = recorder.DoHttpReq(context.Background(), akamai.OpPostSensorData, "https://www.example.com", http.MethodPost, body)
+	})
+	if allocs != 0 {
+		t.Fatal("expected no allocations, got:", allocs)
+	}
+}
@@ -0,0 +1,178 @@
+package akamaitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// ErrNoRecordedResponse is returned by ReplayTransport for requests without a recorded response.
+var ErrNoRecordedResponse = errors.New("akamai-sdk-go/akamaitest: no recorded response")
+
+// RecordedResponse is an HTTP response recorded in a Cassette.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Cassette holds HTTP responses keyed by the request they were received for, for recording with
+// RecordingTransport and replaying with ReplayTransport. Requests are keyed by their method, URL path and
+// a hash of their body, so the API key and the host of the API do not matter. Recording the same
+// request twice keeps the last response.
+//
+// Cassette is safe for usage by multiple goroutines.
+type Cassette struct {
+	mu        sync.Mutex
+	responses map[string]RecordedResponse
+}
+
+// NewCassette creates an empty Cassette.
+func NewCassette() *Cassette {
+	return &Cassette{responses: make(map[string]RecordedResponse)}
+}
+
+// LoadCassette loads a Cassette from the JSON file at path, as written by Cassette.Save.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCassette()
+	if err = json.Unmarshal(data, &c.responses); err != nil {
+		return nil, fmt.Errorf("akamai-sdk-go/akamaitest: invalid cassette %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Save writes the recorded responses to the file at path as indented JSON, suitable for checking in as
+// a golden file.
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.responses, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Len returns the number of recorded responses.
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.responses)
+}
+
+// record records response for the request with the given key.
+func (c *Cassette) record(key string, response RecordedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[key] = response
+}
+
+// lookup returns the response recorded for the request with the given key.
+func (c *Cassette) lookup(key string) (RecordedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response, ok := c.responses[key]
+	return response, ok
+}
+
+// RecordingTransport is an http.RoundTripper that makes requests with Base and records the responses in
+// Cassette. Use it as the transport of the client passed to akamai.NewSessionWithClient to record real
+// SolarSystems API responses, then save the cassette and replay it in tests with ReplayTransport.
+type RecordingTransport struct {
+	// Base makes the requests. If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Cassette records the responses. It must not be nil.
+	Cassette *Cassette
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RecordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	key, requestBody, err := readRequestKey(request)
+	if err != nil {
+		return nil, err
+	}
+	// The body was consumed, so send a copy of the request with a fresh one.
+	request = request.Clone(request.Context())
+	request.Body = io.NopCloser(bytes.NewReader(requestBody))
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	response, err := base.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.Cassette.record(key, RecordedResponse{
+		StatusCode: response.StatusCode,
+		Header:     response.Header.Clone(),
+		Body:       string(body),
+	})
+	return response, nil
+}
+
+// ReplayTransport is an http.RoundTripper that responds with the responses recorded in Cassette, without
+// making any request. Requests without a recorded response fail with ErrNoRecordedResponse.
+type ReplayTransport struct {
+	// Cassette holds the recorded responses. It must not be nil.
+	Cassette *Cassette
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ReplayTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	key, _, err := readRequestKey(request)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded, ok := t.Cassette.lookup(key)
+	if !ok {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoRecordedResponse, request.Method, request.URL.Path)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       request,
+	}, nil
+}
+
+// readRequestKey reads and closes the body of request and returns it along with the Cassette key of request.
+func readRequestKey(request *http.Request) (key string, body []byte, err error) {
+	if request.Body != nil {
+		body, err = io.ReadAll(request.Body)
+		_ = request.Body.Close()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	hash := sha256.Sum256(body)
+	return request.Method + " " + request.URL.Path + " " + hex.EncodeToString(hash[:]), body, nil
+}
@@ -0,0 +1,65 @@
+package akamaitest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	akamai "github.com/SolarSystems-Software/akamai-sdk-go"
+)
+
+const testUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0.0.0 Safari/537.36"
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"recorded"}`))
+	}))
+	defer server.Close()
+
+	req := &akamai.GenerateRequest{
+		UserAgent: testUserAgent,
+		Version:   akamai.Version175,
+		PageURL:   "https://www.example.com/product",
+	}
+
+	// Record
+	cassette := NewCassette()
+	session := akamai.NewSessionWithClient("key", &http.Client{Transport: &RecordingTransport{Cassette: cassette}})
+	akamai.WithBaseURL(server.URL)(&session)
+	if _, err := session.GenerateSensorData(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if v := cassette.Len(); v != 1 {
+		t.Fatal("expected 1 recorded response, got:", v)
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := cassette.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	// Replay
+	loaded, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session = akamai.NewSessionWithClient("other-key", &http.Client{Transport: &ReplayTransport{Cassette: loaded}})
+	akamai.WithBaseURL(server.URL)(&session)
+	resp, err := session.GenerateSensorData(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Payload != "recorded" {
+		t.Fatal("unexpected payload:", resp.Payload)
+	}
+
+	req.PageURL = "https://www.example.com/other"
+	if _, err = session.GenerateSensorData(context.Background(), req); !errors.Is(err, ErrNoRecordedResponse) {
+		t.Fatal("expected ErrNoRecordedResponse, got:", err)
+	}
+}
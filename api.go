@@ -0,0 +1,245 @@
+package akamai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// apiEndpoint is a SolarSystems API endpoint.
+type apiEndpoint struct {
+	// path is the path of the endpoint relative to the API base URL.
+	path string
+
+	// authenticated reports if requests to the endpoint carry the API key.
+	authenticated bool
+
+	// successCodes are the HTTP status codes of successful responses.
+	successCodes []int
+}
+
+// createdOnly are the success codes of the generation endpoints, which all respond with 201 Created.
+var createdOnly = []int{http.StatusCreated}
+
+var (
+	sensorEndpoint = apiEndpoint{path: "/v1/sensor/generate", authenticated: true, successCodes: createdOnly}
+	pixelEndpoint  = apiEndpoint{path: "/v1/pixel/generate", successCodes: createdOnly}
+	secCptEndpoint = apiEndpoint{path: "/v1/sec-cpt/generate", authenticated: true, successCodes: createdOnly}
+)
+
+// maxAPIResponseBytes is the maximum size of a SolarSystems API response body. Payloads are a few kilobytes,
+// so larger responses are rejected with ErrBodyTooLarge.
+const maxAPIResponseBytes = 4 << 20
+
+// reservedAPIHeaders are the canonical names of the API request headers that cannot be set with WithAPIHeaders.
+var reservedAPIHeaders = map[string]struct{}{
+	"X-Api-Key":    {},
+	"Content-Type": {},
+}
+
+// WithAPIHeaders sets additional HTTP request headers to send with every SolarSystems API request, e.g.
+// headers required by a corporate proxy. The User-Agent header can be overridden; the reserved x-api-key and
+// Content-Type headers cannot and are ignored if present in headers.
+func WithAPIHeaders(headers http.Header) SessionOption {
+	headers = headers.Clone()
+	return func(session *Session) {
+		session.apiHeaders = headers
+	}
+}
+
+// WithAPICallTimeout sets the timeout of each SolarSystems API request, so that a stuck request cannot hang
+// Session.Generate even if it is called with a context without a deadline. The timeout is derived from the
+// context passed to the API method, so an earlier deadline of that context still applies. If timeout is
+// zero, which is the default, API requests only time out according to the context and the HTTP client.
+//
+// The timeout applies to each attempt separately, including the retries of the session's RetryPolicy, and
+// covers reading the response. An attempt that times out fails with context.DeadlineExceeded and is not
+// retried, as only API responses with a transient status code are.
+func WithAPICallTimeout(timeout time.Duration) SessionOption {
+	return func(session *Session) {
+		session.apiCallTimeout = timeout
+	}
+}
+
+// apiRequestBodyPool is a pool of *bytes.Buffer used to encode API request bodies.
+var apiRequestBodyPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// encodeAPIRequest encodes payload as JSON into buf and returns the encoded bytes, which are identical to
+// the output of json.Marshal. The returned slice is only valid until buf is modified.
+func encodeAPIRequest(buf *bytes.Buffer, payload any) ([]byte, error) {
+	if err := json.NewEncoder(buf).Encode(payload); err != nil {
+		return nil, err
+	}
+	// Encoder.Encode terminates each value with a newline, unlike json.Marshal.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// putAPIRequestBody resets buf and returns it to apiRequestBodyPool.
+func putAPIRequestBody(buf *bytes.Buffer) {
+	buf.Reset()
+	apiRequestBodyPool.Put(buf)
+}
+
+// doAPIRequest sends payload encoded as JSON to the given API endpoint and decodes the response into v.
+//
+// Requests are subject to the session's circuit breaker and concurrency limit, if any.
+// Requests failing with a transient HTTP status code are retried according to the session's RetryPolicy,
+// if any, waiting for at least the duration of the Retry-After HTTP response header on rate limit errors.
+// Once all attempts are exhausted, the last ApiOperationError (or RateLimitError) is returned. If the circuit
+// breaker opens between attempts, ErrCircuitOpen is returned joined with the error of the last attempt.
+func (session Session) doAPIRequest(ctx context.Context, endpoint apiEndpoint, payload, v any) error {
+	buf := apiRequestBodyPool.Get().(*bytes.Buffer)
+	encoded, err := encodeAPIRequest(buf, payload)
+	if err != nil {
+		putAPIRequestBody(buf)
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		allowed, trial := true, false
+		if session.breaker != nil {
+			allowed, trial = session.breaker.allow(session.now())
+		}
+		if !allowed {
+			if attempt == 1 {
+				putAPIRequestBody(buf)
+				return ErrCircuitOpen
+			}
+			return errors.Join(ErrCircuitOpen, err)
+		}
+		if err = session.acquireAPISlot(ctx); err != nil {
+			if session.breaker != nil {
+				session.breaker.done(ctx, trial, err, session.now())
+			}
+			if attempt == 1 {
+				putAPIRequestBody(buf)
+			}
+			return err
+		}
+		err = session.sendAPIRequest(ctx, endpoint, encoded, v)
+		session.releaseAPISlot()
+		if session.breaker != nil {
+			session.breaker.done(ctx, trial, err, session.now())
+		}
+		if err == nil {
+			session.countAPICall()
+			if attempt == 1 {
+				// The transport may still read the body of a request that failed, e.g. if the API responded
+				// before reading all of it, so the buffer is only reused if the first attempt succeeded.
+				putAPIRequestBody(buf)
+			}
+		}
+
+		var apiErr ApiOperationError
+		if !errors.As(err, &apiErr) || !session.retry.shouldRetry(attempt, apiErr.StatusCode) {
+			return err
+		}
+
+		// Wait for at least as long as the API asks us to
+		delay := session.retry.delay(attempt)
+		var rateLimitErr RateLimitError
+		if errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter > delay {
+			delay = rateLimitErr.RetryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// sendAPIRequest makes a single request to the given API endpoint. See doAPIRequest.
+func (session Session) sendAPIRequest(ctx context.Context, endpoint apiEndpoint, encoded []byte, v any) error {
+	if session.apiCallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, session.apiCallTimeout)
+		defer cancel()
+	}
+
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		session.apiURL(endpoint.path),
+		bytes.NewReader(encoded),
+	)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("User-Agent", "SolarSystems akamai-sdk-go")
+	for name, values := range session.apiHeaders {
+		name = http.CanonicalHeaderKey(name)
+		if _, reserved := reservedAPIHeaders[name]; !reserved {
+			request.Header[name] = values
+		}
+	}
+	if endpoint.authenticated {
+		request.Header.Set("x-api-key", session.apiKey)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	// statusCode is the response status code reported to the observer.
+	statusCode := 0
+	if observer := session.observer; observer != nil {
+		start := session.now()
+		defer func() {
+			observer.OnAPIRequest(endpoint.path, session.since(start), statusCode)
+		}()
+	}
+
+	response, err := session.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer closeBody(response.Body)
+	statusCode = response.StatusCode
+
+	body, err := LimitedReadAll(response.Body, maxAPIResponseBytes)
+	if err != nil {
+		return err
+	}
+
+	if err = session.expectStatus(response, body, endpoint.successCodes); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// expectStatus returns an ApiOperationError for the given API response with the given body, or a
+// RateLimitError for 429 Too Many Requests, unless its status code is one of want.
+func (session Session) expectStatus(response *http.Response, body []byte, want []int) error {
+	for _, statusCode := range want {
+		if response.StatusCode == statusCode {
+			return nil
+		}
+	}
+
+	rawBody := body
+	if len(rawBody) > MaxRawBodyBytes {
+		rawBody = rawBody[:MaxRawBodyBytes]
+	}
+	err := ApiOperationError{
+		StatusCode: response.StatusCode,
+		Message:    GetMessageFromErrorResponse(body),
+		RawBody:    append([]byte(nil), rawBody...),
+	}
+	if response.StatusCode == http.StatusTooManyRequests {
+		return RateLimitError{
+			ApiOperationError: err,
+			RetryAfter:        parseRetryAfter(response.Header.Get("Retry-After"), session.now()),
+		}
+	}
+	return err
+}
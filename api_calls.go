@@ -0,0 +1,32 @@
+package akamai
+
+// APICallCount returns the number of successful SolarSystems API requests made with the session and all
+// of its copies since it was created or ResetAPICallCount was last called. Each successful request to
+// GenerateSensorData, GeneratePixelPayload or GenerateSecCptPayload (including the ones made by Generate)
+// is counted once; failed requests and retried attempts that failed are not counted. This allows callers
+// to reconcile their usage against their billing.
+//
+// APICallCount is safe for usage by multiple goroutines. It always returns zero for a Session that was
+// not created with one of the utility functions.
+func (session Session) APICallCount() uint64 {
+	if session.apiCalls == nil {
+		return 0
+	}
+	return session.apiCalls.Load()
+}
+
+// ResetAPICallCount resets the counter returned by APICallCount to zero and returns its previous value.
+// It is safe for usage by multiple goroutines.
+func (session Session) ResetAPICallCount() uint64 {
+	if session.apiCalls == nil {
+		return 0
+	}
+	return session.apiCalls.Swap(0)
+}
+
+// countAPICall increments the counter returned by APICallCount.
+func (session Session) countAPICall() {
+	if session.apiCalls != nil {
+		session.apiCalls.Add(1)
+	}
+}
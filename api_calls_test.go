@@ -0,0 +1,37 @@
+package akamai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAPICallCount(t *testing.T) {
+	session, _ := newTestSession(t)
+	browser := &testBrowser{}
+
+	if err := session.Generate(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		1,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// One sensor data and one pixel challenge request
+	if v := session.APICallCount(); v != 2 {
+		t.Fatal("expected 2 API calls, got:", v)
+	}
+	if v := session.ResetAPICallCount(); v != 2 {
+		t.Fatal("expected reset to return 2, got:", v)
+	}
+	if v := session.APICallCount(); v != 0 {
+		t.Fatal("expected 0 API calls after reset, got:", v)
+	}
+
+	if v := (Session{}).APICallCount(); v != 0 {
+		t.Fatal("expected 0 API calls for zero Session, got:", v)
+	}
+}
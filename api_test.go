@@ -0,0 +1,139 @@
+package akamai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithAPIHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get("Proxy-Token"); v != "token" {
+			t.Error("unexpected Proxy-Token header:", v)
+		}
+		if v := r.Header.Get("x-api-key"); v != "key" {
+			t.Error("unexpected x-api-key header:", v)
+		}
+		if v := r.Header.Get("Content-Type"); v != "application/json" {
+			t.Error("unexpected Content-Type header:", v)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("key", WithBaseURL(server.URL), WithAPIHeaders(http.Header{
+		"Proxy-Token":  {"token"},
+		"x-api-key":    {"other"},
+		"Content-Type": {"text/plain"},
+	}))
+	if _, err := session.GenerateSensorData(context.Background(), testGenerateRequest()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIRequestCancelled(t *testing.T) {
+	for name, partialBody := range map[string]bool{"during Do": false, "during body read": true} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				if partialBody {
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"sensorData":`))
+					w.(http.Flusher).Flush()
+				}
+				<-r.Context().Done()
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			session := NewSessionWithOptions("", WithBaseURL(server.URL))
+			if _, err := session.GenerateSensorData(ctx, testGenerateRequest()); !errors.Is(err, context.Canceled) {
+				t.Fatal("expected context.Canceled, got:", err)
+			}
+		})
+	}
+}
+
+func TestWithAPICallTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL), WithAPICallTimeout(50*time.Millisecond))
+	start := time.Now()
+	if _, err := session.GenerateSensorData(context.Background(), testGenerateRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected context.DeadlineExceeded, got:", err)
+	}
+	if v := time.Since(start); v > 2*time.Second {
+		t.Fatal("API request did not time out in time:", v)
+	}
+
+	// An earlier deadline of the parent context still applies.
+	session = NewSessionWithOptions("", WithBaseURL(server.URL), WithAPICallTimeout(time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := session.GenerateSensorData(ctx, testGenerateRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected context.DeadlineExceeded, got:", err)
+	}
+}
+
+func TestEncodeAPIRequest(t *testing.T) {
+	req := &GenerateRequest{
+		UserAgent: testUserAgent,
+		Version:   Version175,
+		PageURL:   testPageURL + "?a=<b>&c=\"d\"",
+		Abck:      testValidAbck,
+	}
+	expected, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	encoded, err := encodeAPIRequest(&buf, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, expected) {
+		t.Fatalf("unexpected encoding: %s", encoded)
+	}
+}
+
+func BenchmarkEncodeAPIRequestMarshal(b *testing.B) {
+	req := testGenerateRequest()
+	req.Abck = testValidAbck
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeAPIRequestPool(b *testing.B) {
+	req := testGenerateRequest()
+	req.Abck = testValidAbck
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := apiRequestBodyPool.Get().(*bytes.Buffer)
+		if _, err := encodeAPIRequest(buf, req); err != nil {
+			b.Fatal(err)
+		}
+		putAPIRequestBody(buf)
+	}
+}
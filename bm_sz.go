@@ -0,0 +1,53 @@
+package akamai
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BmSzLifetime is the lifetime of the bm_sz cookie. Akamai Bot Manager sets the cookie with a Max-Age of
+// four hours. Callers keeping track of when they received a bm_sz cookie can use it to decide whether
+// to refetch the page before generating.
+const BmSzLifetime = 4 * time.Hour
+
+var (
+	bmSzHashExpr   = regexp.MustCompile(`^[0-9A-F]{32}$`)
+	bmSzNumberExpr = regexp.MustCompile(`^\d+$`)
+)
+
+// RequiresBmSz reports if generating sensor data for the given web SDK version requires the bm_sz cookie,
+// which is the case for version 2 and newer (see Version.AtLeast). Session.Generate only reads and sends the
+// bm_sz cookie for such versions.
+func RequiresBmSz(v Version) bool {
+	return v.AtLeast(Version2)
+}
+
+// IsBmSzMalformed reports if the given bm_sz cookie value is empty or malformed, in which case it cannot be
+// used for generation.
+//
+// A bm_sz value consists of at least four `~`-delimited fields: a 32 character uppercase hexadecimal hash,
+// encrypted data, and two decimal numbers as the last two fields, e.g. `<hash>~YAAQ...~4277302~3556675`.
+// The value does not carry its issue time in a decodable form, so IsBmSzMalformed cannot tell if a
+// well-formed value has expired; callers keeping track of when they received it can use BmSzLifetime.
+func IsBmSzMalformed(value string) bool {
+	parts := strings.Split(value, "~")
+	if len(parts) < 4 {
+		return true
+	}
+
+	return !bmSzHashExpr.MatchString(parts[0]) ||
+		!bmSzNumberExpr.MatchString(parts[len(parts)-2]) ||
+		!bmSzNumberExpr.MatchString(parts[len(parts)-1])
+}
+
+// WithBmSzRefresh makes Session.Generate fetch the page again before generating sensor data for scripts
+// requiring the bm_sz cookie (see RequiresBmSz) if the current bm_sz cookie is missing or malformed according
+// to IsBmSzMalformed. Well-formed cookies are never refreshed, even if they have expired, as their age is
+// unknown to the session; callers holding on to cookies for longer than BmSzLifetime should fetch the page
+// again themselves.
+func WithBmSzRefresh() SessionOption {
+	return func(session *Session) {
+		session.refreshBmSz = true
+	}
+}
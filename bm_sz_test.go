@@ -0,0 +1,36 @@
+package akamai
+
+import "testing"
+
+func TestIsBmSzMalformed(t *testing.T) {
+	const validCookie = `AFBA2A1AAE9B0D5C3F1F5A0E9B2E6F3C~YAAQXmQRAgAAAAB5nJqGAQAAE1yBpQ8e+2Xb4sQ5Q1Vx~4277302~3556675`
+
+	if IsBmSzMalformed(validCookie) {
+		t.Fatal("valid cookie reported as malformed")
+	}
+
+	for _, value := range []string{
+		"",
+		"AFBA2A1AAE9B0D5C3F1F5A0E9B2E6F3C",
+		"AFBA2A1AAE9B0D5C3F1F5A0E9B2E6F3C~YAAQ~abc~3556675",
+		"not-a-hash~YAAQ~4277302~3556675",
+	} {
+		if !IsBmSzMalformed(value) {
+			t.Fatal("malformed cookie reported as well-formed:", value)
+		}
+	}
+}
+
+func TestRequiresBmSz(t *testing.T) {
+	tests := map[Version]bool{
+		Version17:  false,
+		Version175: false,
+		Version2:   true,
+		"":         false,
+	}
+	for version, expected := range tests {
+		if v := RequiresBmSz(version); v != expected {
+			t.Fatalf("unexpected result for %q: %t", version, v)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is an error caused by the API methods of Session if the circuit breaker of the session is
+// open, meaning the SolarSystems API recently failed repeatedly. See WithCircuitBreaker.
+var ErrCircuitOpen = errors.New("akamai-sdk-go: API circuit breaker open")
+
+// WithCircuitBreaker makes the session stop making SolarSystems API requests after failureThreshold
+// consecutive requests failed, e.g. during an API outage. API requests then fail immediately with
+// ErrCircuitOpen for the given cooldown, after which a single trial request is made: if it succeeds, requests
+// are made again as usual, otherwise they keep failing for another cooldown. This avoids piling up slow,
+// failing requests while the API is down. The circuit breaker is shared by all copies of the session.
+//
+// Requests fail in the sense of the circuit breaker if no valid response is received or if the API responds
+// with a server error or rate limit status code. Other API errors, like invalid API keys, show that the API
+// is available and reset the count, while requests cancelled by their context do not affect it. Each attempt
+// of the session's RetryPolicy counts as a request.
+//
+// WithCircuitBreaker panics if failureThreshold <= 0 or cooldown <= 0.
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) SessionOption {
+	if failureThreshold <= 0 {
+		panic("akamai-sdk-go: failureThreshold <= 0")
+	}
+	if cooldown <= 0 {
+		panic("akamai-sdk-go: cooldown <= 0")
+	}
+
+	return func(session *Session) {
+		session.breaker = &circuitBreaker{threshold: failureThreshold, cooldown: cooldown}
+	}
+}
+
+// circuitBreaker is the state of the circuit breaker set with WithCircuitBreaker.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu sync.Mutex
+
+	// failures is the number of consecutive failed requests.
+	failures int
+
+	// openUntil is the time until which the circuit is open, if failures >= threshold.
+	openUntil time.Time
+
+	// trial reports if the trial request after the cooldown is in flight.
+	trial bool
+}
+
+// allow reports if a request can be made at the given time, and if it is the trial request after the
+// cooldown. If ok is true, the caller must report the outcome of the request with done.
+func (b *circuitBreaker) allow(now time.Time) (ok, trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true, false
+	}
+	if b.trial || now.Before(b.openUntil) {
+		return false, false
+	}
+	b.trial = true
+	return true, true
+}
+
+// done records the outcome of a request made with ctx, allowed by allow, at the given time. trial is the
+// value returned by allow, so that requests allowed before the circuit opened do not end the trial.
+func (b *circuitBreaker) done(ctx context.Context, trial bool, err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if trial {
+		b.trial = false
+	}
+	if err != nil && ctx.Err() != nil {
+		// Requests cancelled by the caller say nothing about the API.
+		return
+	}
+	if err == nil || !isOutageError(err) {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
+
+// isOutageError reports if err, the error of an API request, indicates that the API is unavailable.
+func isOutageError(err error) bool {
+	var apiErr ApiOperationError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// manualClock is a Clock whose time is advanced manually.
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var status atomic.Int64
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	defer server.Close()
+
+	clock := &manualClock{now: time.Date(2023, time.February, 20, 12, 0, 0, 0, time.UTC)}
+	session := NewSessionWithOptions(
+		"",
+		WithBaseURL(server.URL),
+		WithClock(clock),
+		WithCircuitBreaker(2, time.Minute),
+	)
+	generate := func() error {
+		_, err := session.GenerateSensorData(context.Background(), testGenerateRequest())
+		return err
+	}
+
+	// Client errors do not open the circuit.
+	status.Store(http.StatusUnauthorized)
+	for i := 0; i < 3; i++ {
+		if err := generate(); !errors.Is(err, ErrUnauthorized) {
+			t.Fatal("expected ErrUnauthorized, got:", err)
+		}
+	}
+
+	status.Store(http.StatusServiceUnavailable)
+	for i := 0; i < 2; i++ {
+		if err := generate(); errors.Is(err, ErrCircuitOpen) || err == nil {
+			t.Fatal("expected API error, got:", err)
+		}
+	}
+	requests.Store(0)
+	if err := generate(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected ErrCircuitOpen, got:", err)
+	}
+	if v := requests.Load(); v != 0 {
+		t.Fatal("expected no API requests, got:", v)
+	}
+
+	// The failed trial request opens the circuit again.
+	clock.advance(time.Minute)
+	if err := generate(); errors.Is(err, ErrCircuitOpen) || err == nil {
+		t.Fatal("expected API error, got:", err)
+	}
+	if err := generate(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected ErrCircuitOpen, got:", err)
+	}
+
+	// The successful trial request closes the circuit.
+	clock.advance(time.Minute)
+	status.Store(http.StatusCreated)
+	for i := 0; i < 2; i++ {
+		if err := generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWithCircuitBreakerRetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions(
+		"",
+		WithBaseURL(server.URL),
+		WithRetry(3, time.Millisecond),
+		WithCircuitBreaker(1, time.Minute),
+	)
+
+	// The circuit opens after the first attempt, but the error of that attempt is kept.
+	_, err := session.GenerateSensorData(context.Background(), testGenerateRequest())
+	var apiErr ApiOperationError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("expected ErrCircuitOpen joined with ApiOperationError, got:", err)
+	}
+}
+
+func TestWithCircuitBreakerSingleTrial(t *testing.T) {
+	var requests atomic.Int64
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			// Slow request made before the circuit opens
+			arrived <- struct{}{}
+			select {
+			case <-r.Context().Done():
+			case <-finished:
+			}
+			return
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case 3:
+			// Trial request
+			arrived <- struct{}{}
+			select {
+			case <-release:
+			case <-finished:
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	defer server.Close()
+	defer close(finished)
+
+	clock := &manualClock{now: time.Date(2023, time.February, 20, 12, 0, 0, 0, time.UTC)}
+	session := NewSessionWithOptions(
+		"",
+		WithBaseURL(server.URL),
+		WithClock(clock),
+		WithCircuitBreaker(1, time.Minute),
+	)
+	generate := func(ctx context.Context) error {
+		_, err := session.GenerateSensorData(ctx, testGenerateRequest())
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	slowErr := make(chan error, 1)
+	go func() { slowErr <- generate(ctx) }()
+	<-arrived
+
+	if err := generate(context.Background()); errors.Is(err, ErrCircuitOpen) || err == nil {
+		t.Fatal("expected API error, got:", err)
+	}
+	clock.advance(time.Minute)
+	trialErr := make(chan error, 1)
+	go func() { trialErr <- generate(context.Background()) }()
+	<-arrived
+
+	// The slow request finishing does not end the trial.
+	cancel()
+	if err := <-slowErr; err == nil {
+		t.Fatal("expected error from cancelled request")
+	}
+	if err := generate(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected ErrCircuitOpen during trial, got:", err)
+	}
+
+	close(release)
+	if err := <-trialErr; err != nil {
+		t.Fatal(err)
+	}
+	if err := generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v := requests.Load(); v != 4 {
+		t.Fatal("expected 4 API requests, got:", v)
+	}
+}
@@ -0,0 +1,65 @@
+package akamai
+
+import (
+	"context"
+	"time"
+)
+
+// Clock provides the current time to a Session. All time-dependent logic of a Session, like parsing
+// Retry-After HTTP response headers and measuring the durations reported to its Observer, reads the time
+// through its Clock, which allows callers to write deterministic tests. Clocks implementing ClockTimer
+// also control the delays of generation.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// ClockTimer is an optional interface of a Clock, implemented by clocks that also control how long a Session
+// waits, e.g. between sensor data tries (see GenerateConfig.InterPostDelay). Sessions whose Clock does not
+// implement it wait in real time.
+type ClockTimer interface {
+	// After returns a channel that receives the current time once d has elapsed, like time.After.
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClock sets the Clock of the session. If clock is nil, the system clock is used, which is the default.
+func WithClock(clock Clock) SessionOption {
+	return func(session *Session) {
+		session.clock = clock
+	}
+}
+
+// now returns the current time according to the session's Clock.
+func (session Session) now() time.Time {
+	if session.clock == nil {
+		return time.Now()
+	}
+	return session.clock.Now()
+}
+
+// sleep waits for d according to the session's Clock (see ClockTimer). It returns ctx.Err() if ctx is done
+// before.
+func (session Session) sleep(ctx context.Context, d time.Duration) error {
+	if timer, ok := session.clock.(ClockTimer); ok {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.After(d):
+			return nil
+		}
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// since returns the time elapsed since t according to the session's Clock.
+func (session Session) since(t time.Time) time.Duration {
+	return session.now().Sub(t)
+}
@@ -0,0 +1,37 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fixedClock is a Clock always returning the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestWithClock(t *testing.T) {
+	now := time.Date(2023, time.February, 20, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", now.Add(30*time.Second).Format(http.TimeFormat))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL), WithClock(fixedClock(now)))
+	_, err := session.GenerateSensorData(context.Background(), testGenerateRequest())
+
+	var rateLimitErr RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatal("expected RateLimitError, got:", err)
+	}
+	if rateLimitErr.RetryAfter != 30*time.Second {
+		t.Fatal("unexpected RetryAfter:", rateLimitErr.RetryAfter)
+	}
+}
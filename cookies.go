@@ -0,0 +1,38 @@
+package akamai
+
+import (
+	"net/http"
+	"time"
+)
+
+// ParseAkamaiCookies returns the Akamai Bot Manager cookies (see AkamaiCookieNames) set by the Set-Cookie
+// headers of an HTTP response, keyed by name. This allows DoHttpReqFunc implementations with access to
+// the raw response headers to track the cookies without a cookie jar. extraNames are the names of other
+// cookies to return, such as the pixel cookie of the website (see GenerateConfig.PixelCookie).
+//
+// If a cookie is set multiple times, the last value wins. Cookies that are deleted, i.e. set with a negative
+// Max-Age or, without Max-Age, an expiry before now, are returned with an empty value, so callers can
+// remove them. now is usually time.Now(), or the current time of the Clock of the session (see WithClock).
+// Cookies that are not set are omitted.
+func ParseAkamaiCookies(header http.Header, now time.Time, extraNames ...string) map[string]string {
+	names := make(map[string]struct{}, len(AkamaiCookieNames)+len(extraNames))
+	for _, name := range AkamaiCookieNames {
+		names[name] = struct{}{}
+	}
+	for _, name := range extraNames {
+		names[name] = struct{}{}
+	}
+
+	cookies := make(map[string]string)
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		if _, ok := names[cookie.Name]; !ok {
+			continue
+		}
+		if cookie.MaxAge < 0 || (cookie.MaxAge == 0 && !cookie.Expires.IsZero() && cookie.Expires.Before(now)) {
+			cookies[cookie.Name] = ""
+		} else {
+			cookies[cookie.Name] = cookie.Value
+		}
+	}
+	return cookies
+}
@@ -0,0 +1,50 @@
+package akamai
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAkamaiCookies(t *testing.T) {
+	header := http.Header{
+		"Content-Type": {"text/html"},
+		"Set-Cookie": {
+			`_abck=` + testInvalidAbck + `; Domain=.example.com; Path=/; Expires=Wed, 20 Feb 2030 12:00:00 GMT; Max-Age=31536000; Secure`,
+			`bm_sz=AFBA2A1AAE9B0D5C3F1F5A0E9B2E6F3C~YAAQXmQRAgAAAAB5nJqGAQAAE1yBpQ8e+2Xb4sQ5Q1Vx~4277302~3556675; Domain=.example.com; Path=/; Max-Age=14400`,
+			`session_id=abc123; Path=/; HttpOnly`,
+			`_abck=` + testValidAbck + `; Domain=.example.com; Path=/; Max-Age=31536000; Secure`,
+			`ak_bmsc=; Domain=.example.com; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT`,
+			`pixel_solved=1; Path=/`,
+		},
+	}
+
+	expected := map[string]string{
+		"_abck":   testValidAbck,
+		"bm_sz":   "AFBA2A1AAE9B0D5C3F1F5A0E9B2E6F3C~YAAQXmQRAgAAAAB5nJqGAQAAE1yBpQ8e+2Xb4sQ5Q1Vx~4277302~3556675",
+		"ak_bmsc": "",
+	}
+	now := time.Date(2023, time.February, 20, 12, 0, 0, 0, time.UTC)
+	if v := ParseAkamaiCookies(header, now); !reflect.DeepEqual(v, expected) {
+		t.Fatal("unexpected cookies:", v)
+	}
+
+	expected["pixel_solved"] = "1"
+	if v := ParseAkamaiCookies(header, now, "pixel_solved"); !reflect.DeepEqual(v, expected) {
+		t.Fatal("unexpected cookies:", v)
+	}
+
+	if v := ParseAkamaiCookies(http.Header{}, now); len(v) != 0 {
+		t.Fatal("unexpected cookies:", v)
+	}
+
+	// Cookies without Max-Age expire according to now.
+	header = http.Header{"Set-Cookie": {`sbsd=abc; Path=/; Expires=Wed, 20 Feb 2030 12:00:00 GMT`}}
+	if v := ParseAkamaiCookies(header, now); v["sbsd"] != "abc" {
+		t.Fatal("unexpected cookies:", v)
+	}
+	if v := ParseAkamaiCookies(header, now.AddDate(10, 0, 0)); v["sbsd"] != "" || len(v) != 1 {
+		t.Fatal("unexpected cookies:", v)
+	}
+}
@@ -0,0 +1,71 @@
+package akamai
+
+// CostEstimate is the expected number of SolarSystems API calls of a call to Session.Generate, as computed by
+// EstimateCost. Each successful API call costs one credit.
+type CostEstimate struct {
+	// MinAPICalls is the number of API calls if the first sensor data POST request results in a valid _abck
+	// cookie and every pixel challenge is already solved.
+	MinAPICalls int
+
+	// MaxAPICalls is the number of API calls if all sensor data tries are used, every sensor data payload
+	// is generated again GenerateConfig.SensorPayloadRetries times and every pixel challenge needs solving.
+	MaxAPICalls int
+}
+
+// EstimateCost is like Session.EstimateCost for a session without options. Callers whose session finds
+// pixel challenges with WithPixelURLPatterns, or inline scripts with WithVersionDetector, should use the
+// method instead, as this function does not count them.
+func EstimateCost(pageBody []byte, cfg GenerateConfig) CostEstimate {
+	return Session{}.EstimateCost(pageBody, cfg)
+}
+
+// EstimateCost estimates the number of SolarSystems API calls Session.GenerateWithConfig makes for the given
+// page and config, without making any request, so that callers can skip pages exceeding their budget. The
+// estimate counts one call per sensor data try, plus up to GenerateConfig.SensorPayloadRetries calls per try
+// for invalid payloads, one per pixel challenge and one for the sec_cpt challenge if GenerateConfig.SolveSecCpt
+// is set.
+// Retries of failed API calls (see WithRetry) are not counted, as they only happen on failures.
+//
+// Pixel challenges that are already solved cost nothing, which cannot be known before fetching their
+// scripts, so they only count towards MaxAPICalls. Dry runs (see GenerateConfig.DryRun) cost nothing.
+func (session Session) EstimateCost(pageBody []byte, cfg GenerateConfig) CostEstimate {
+	var estimate CostEstimate
+	if cfg.DryRun || (cfg.Strict && !LooksLikeHTML(pageBody)) {
+		return estimate
+	}
+
+	// Sensor data
+	hasScript, _, _ := GetScriptURL(pageBody)
+	if !hasScript && cfg.InlineSensorPostURL != "" {
+		hasScript, _ = session.getInlineScript(pageBody)
+	}
+	if hasScript {
+		maxTries := cfg.SensorMaxTries
+		if cfg.AutoExtendTries && maxTries < maxAutoExtendedSensorTries {
+			maxTries = maxAutoExtendedSensorTries
+		}
+		callsPerTry := 1
+		if cfg.SensorPayloadRetries > 0 {
+			callsPerTry += cfg.SensorPayloadRetries
+		}
+		estimate.MinAPICalls++
+		estimate.MaxAPICalls += maxTries * callsPerTry
+	}
+
+	// Pixel challenges, which are not solved at all if one lacks its HTML variable
+	locations := GetAllPixelChallengeScriptURLsMatching(pageBody, session.pixelPatterns)
+	for _, location := range locations {
+		if _, err := GetPixelChallengeHtmlVarFor(pageBody, location.ScriptURL); err != nil {
+			locations = nil
+			break
+		}
+	}
+	estimate.MaxAPICalls += len(locations)
+
+	// sec_cpt challenge
+	if ok, _ := GetSecCptChallenge(pageBody); ok && cfg.SolveSecCpt {
+		estimate.MinAPICalls++
+		estimate.MaxAPICalls++
+	}
+	return estimate
+}
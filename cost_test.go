@@ -0,0 +1,73 @@
+package akamai
+
+import (
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	cfg := DefaultGenerateConfig()
+	if v := EstimateCost([]byte(testPageBody), cfg); v != (CostEstimate{MinAPICalls: 1, MaxAPICalls: 3}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+
+	cfg.AutoExtendTries = true
+	if v := EstimateCost([]byte(testPageBody), cfg); v != (CostEstimate{MinAPICalls: 1, MaxAPICalls: maxAutoExtendedSensorTries + 1}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+
+	cfg.AutoExtendTries = false
+	cfg.SensorPayloadRetries = 2
+	if v := EstimateCost([]byte(testPageBody), cfg); v != (CostEstimate{MinAPICalls: 1, MaxAPICalls: 2*3 + 1}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+
+	cfg = DefaultGenerateConfig()
+	page := `<html><script src="/_sec/cp_challenge/ak-challenge-4-3.js"></script>` +
+		`<script>var _acxj=[];</script></html>`
+	if v := EstimateCost([]byte(page), cfg); v != (CostEstimate{}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+	cfg.SolveSecCpt = true
+	if v := EstimateCost([]byte(page), cfg); v != (CostEstimate{MinAPICalls: 1, MaxAPICalls: 1}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+	cfg.InlineSensorPostURL = "/sensor"
+	if v := EstimateCost([]byte(page), cfg); v != (CostEstimate{MinAPICalls: 2, MaxAPICalls: 3}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+
+	// Pixel challenges only count towards MaxAPICalls, as they may already be solved
+	page = `<html><script src="/aBc-dEf/gHi"></script>` +
+		`<script>bazadebezolkohpepadr="1111"</script><script src="https://www.example.com/akam/13/7f8e9d"></script>` +
+		`<script>bazadebezolkohpepadr="2222"</script><script src="https://www.example.com/akam/13/1a2b3c"></script></html>`
+	cfg = DefaultGenerateConfig()
+	if v := EstimateCost([]byte(page), cfg); v != (CostEstimate{MinAPICalls: 1, MaxAPICalls: 2 + 2}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+
+	cfg.DryRun = true
+	if v := EstimateCost([]byte(testPageBody), cfg); v != (CostEstimate{}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+	if v := EstimateCost([]byte(`<html></html>`), DefaultGenerateConfig()); v != (CostEstimate{}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+}
+
+func TestSessionEstimateCost(t *testing.T) {
+	page, err := os.ReadFile("tests/proxied_pixel.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultGenerateConfig()
+	if v := EstimateCost(page, cfg); v != (CostEstimate{MinAPICalls: 1, MaxAPICalls: 2}) {
+		t.Fatalf("unexpected estimate: %+v", v)
+	}
+	session := NewSessionWithOptions("", WithPixelURLPatterns([]*regexp.Regexp{regexp.MustCompile(`src="(/static-assets/px/\w+)"`)}))
+	if v := session.EstimateCost(page, cfg); v != (CostEstimate{MinAPICalls: 1, MaxAPICalls: 2 + 1}) {
+		t.Fatalf("unexpected estimate with pixel URL patterns: %+v", v)
+	}
+}
@@ -0,0 +1,61 @@
+package akamai
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ErrUnsupportedContentEncoding is an error caused by DecompressBody if the content encoding is not supported.
+var ErrUnsupportedContentEncoding = errors.New("akamai-sdk-go: unsupported content encoding")
+
+// DecompressBody decompresses the given response body according to the value of the Content-Encoding
+// HTTP response header. The gzip, deflate and br encodings are supported, and multiple encodings
+// (e.g. "gzip, br") are decoded in reverse order. An empty or "identity" encoding returns body as is.
+//
+// DoHttpReqFunc implementations MUST return decompressed response bodies, as the parsing functions
+// (like GetSdkVersion) operate on plain text. Implementations using an HTTP client that does not
+// decompress response bodies automatically can use DecompressBody to do so.
+func DecompressBody(contentEncoding string, body []byte) ([]byte, error) {
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+
+		var reader io.Reader
+		switch encoding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			r, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			reader = r
+		case "deflate":
+			// deflate is zlib wrapped according to the HTTP specification, however some servers send raw deflate.
+			if r, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+				reader = r
+			} else {
+				reader = flate.NewReader(bytes.NewReader(body))
+			}
+		case "br":
+			reader = brotli.NewReader(bytes.NewReader(body))
+		default:
+			return nil, errors.Join(ErrUnsupportedContentEncoding, fmt.Errorf("content encoding: %s", encoding))
+		}
+
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		body = decompressed
+	}
+	return body, nil
+}
@@ -0,0 +1,61 @@
+package akamai
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestDecompressBody(t *testing.T) {
+	compress := func(newWriter func(w io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		_, _ = w.Write([]byte(testSdkScript))
+		_ = w.Close()
+		return buf.Bytes()
+	}
+
+	tests := map[string][]byte{
+		"": []byte(testSdkScript),
+		"gzip": compress(func(w io.Writer) io.WriteCloser {
+			return gzip.NewWriter(w)
+		}),
+		"deflate": compress(func(w io.Writer) io.WriteCloser {
+			return zlib.NewWriter(w)
+		}),
+		"br": compress(func(w io.Writer) io.WriteCloser {
+			return brotli.NewWriter(w)
+		}),
+	}
+	tests["DEFLATE"] = compress(func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	})
+
+	// gzip is applied first, then br.
+	var buf bytes.Buffer
+	w := brotli.NewWriter(&buf)
+	_, _ = w.Write(tests["gzip"])
+	_ = w.Close()
+	tests["gzip, br"] = buf.Bytes()
+
+	for encoding, body := range tests {
+		decompressed, err := DecompressBody(encoding, body)
+		if err != nil {
+			t.Fatalf("err != nil for %q: %s", encoding, err)
+		}
+		if string(decompressed) != testSdkScript {
+			t.Fatalf("unexpected body for %q: %s", encoding, decompressed)
+		}
+	}
+
+	if _, err := DecompressBody("compress", nil); !errors.Is(err, ErrUnsupportedContentEncoding) {
+		t.Fatal("expected ErrUnsupportedContentEncoding, got:", err)
+	}
+}
@@ -2,9 +2,11 @@ package akamai
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // GetMessageFromErrorResponse gets the `message` JSON field from the given response body.
@@ -20,6 +22,21 @@ func GetMessageFromErrorResponse(body []byte) string {
 	return response.Message
 }
 
+var (
+	// ErrUnauthorized matches (see errors.Is) an ApiOperationError with HTTP status code 401, which is
+	// caused by a missing or invalid API key.
+	ErrUnauthorized = errors.New("akamai-sdk-go: unauthorized")
+
+	// ErrForbidden matches (see errors.Is) an ApiOperationError with HTTP status code 403, which is
+	// caused by an API key that is not allowed to use the requested endpoint, e.g. an expired API key.
+	ErrForbidden = errors.New("akamai-sdk-go: forbidden")
+
+	// ErrUnexpectedStatus matches (see errors.Is) an ApiOperationError with a 2xx HTTP status code the
+	// endpoint is not expected to respond with. The generation endpoints of the SolarSystems API all respond
+	// with 201 Created, so mocks of the API responding with e.g. 200 OK cause this error.
+	ErrUnexpectedStatus = errors.New("akamai-sdk-go: unexpected success status code")
+)
+
 // ApiOperationError represents a generic API request failure due to a bad HTTP status code.
 type ApiOperationError struct {
 	// StatusCode is the HTTP response status code that caused the error.
@@ -27,8 +44,15 @@ type ApiOperationError struct {
 
 	// Message is the error message provided by the server.
 	Message string
+
+	// RawBody is the response body, truncated to MaxRawBodyBytes. It allows callers to inspect error responses
+	// whose message cannot be parsed by GetMessageFromErrorResponse.
+	RawBody []byte
 }
 
+// MaxRawBodyBytes is the maximum length of ApiOperationError.RawBody.
+const MaxRawBodyBytes = 4 << 10
+
 func (err ApiOperationError) Error() string {
 	var builder strings.Builder
 	builder.WriteString("akamai-sdk-go: API operation failed with HTTP ")
@@ -36,10 +60,64 @@ func (err ApiOperationError) Error() string {
 	builder.WriteString(" ")
 	builder.WriteString(http.StatusText(err.StatusCode))
 
+	if isSuccessStatus(err.StatusCode) {
+		builder.WriteString("; unexpected success status code")
+	}
 	if err.Message != "" {
 		builder.WriteString("; ")
 		builder.WriteString(err.Message)
+	} else if len(err.RawBody) > 0 {
+		builder.WriteString("; unparsed response body of ")
+		builder.WriteString(strconv.Itoa(len(err.RawBody)))
+		builder.WriteString(" bytes, see RawBody")
 	}
 
 	return builder.String()
 }
+
+// Is reports if err matches target. An ApiOperationError matches ErrUnauthorized, ErrForbidden and
+// ErrUnexpectedStatus depending on its status code, which allows callers to detect API key problems and
+// misbehaving mocks with errors.Is.
+func (err ApiOperationError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return err.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return err.StatusCode == http.StatusForbidden
+	case ErrUnexpectedStatus:
+		return isSuccessStatus(err.StatusCode)
+	default:
+		return false
+	}
+}
+
+// RateLimitError is an ApiOperationError with HTTP status code 429, caused by exceeding the rate limit
+// of the SolarSystems API.
+type RateLimitError struct {
+	ApiOperationError
+
+	// RetryAfter is the duration to wait before retrying, as reported by the Retry-After HTTP response
+	// header. It is zero if the header is missing or invalid.
+	RetryAfter time.Duration
+}
+
+// Unwrap returns the underlying ApiOperationError.
+func (err RateLimitError) Unwrap() error {
+	return err.ApiOperationError
+}
+
+// parseRetryAfter parses the value of a Retry-After HTTP response header, which is either a number of
+// seconds or an HTTP date. It returns zero if the value is invalid or in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
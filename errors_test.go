@@ -0,0 +1,160 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApiOperationErrorIs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"invalid API key"}`))
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL))
+	_, err := session.GenerateSensorData(context.Background(), testGenerateRequest())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatal("expected ErrUnauthorized, got:", err)
+	}
+	if errors.Is(err, ErrForbidden) {
+		t.Fatal("401 error matches ErrForbidden")
+	}
+
+	var apiErr ApiOperationError
+	if !errors.As(err, &apiErr) || apiErr.Message != "invalid API key" {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !errors.Is(ApiOperationError{StatusCode: http.StatusForbidden}, ErrForbidden) {
+		t.Fatal("403 error does not match ErrForbidden")
+	}
+}
+
+func TestApiOperationErrorRawBody(t *testing.T) {
+	body := "<html><body>Bad Gateway</body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL))
+	_, err := session.GenerateSensorData(context.Background(), testGenerateRequest())
+
+	var apiErr ApiOperationError
+	if !errors.As(err, &apiErr) || apiErr.Message != "" || string(apiErr.RawBody) != body {
+		t.Fatal("unexpected error:", err)
+	}
+	if !strings.Contains(err.Error(), "see RawBody") {
+		t.Fatal("unexpected error message:", err)
+	}
+
+	body = strings.Repeat("a", MaxRawBodyBytes+1)
+	_, err = session.GenerateSensorData(context.Background(), testGenerateRequest())
+	if !errors.As(err, &apiErr) || len(apiErr.RawBody) != MaxRawBodyBytes {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+func TestApiOperationErrorUnexpectedStatus(t *testing.T) {
+	statusCode := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL))
+	endpoints := map[string]func() error{
+		"sensor": func() error {
+			_, err := session.GenerateSensorData(context.Background(), testGenerateRequest())
+			return err
+		},
+		"pixel": func() error {
+			_, err := session.GeneratePixelPayload(context.Background(), &PixelSolveRequest{
+				UserAgent: testUserAgent,
+				HtmlVar:   1234,
+				ScriptVar: "def",
+			})
+			return err
+		},
+		"sec_cpt": func() error {
+			_, err := session.GenerateSecCptPayload(context.Background(), &SecCptSolveRequest{
+				UserAgent:     testUserAgent,
+				PageURL:       testPageURL,
+				ChallengePath: "/_sec/cp_challenge/ak-challenge-4-3.js",
+			})
+			return err
+		},
+	}
+	for _, statusCode = range []int{http.StatusOK, http.StatusNoContent} {
+		for name, generate := range endpoints {
+			err := generate()
+			var apiErr ApiOperationError
+			if !errors.Is(err, ErrUnexpectedStatus) || !errors.As(err, &apiErr) || apiErr.StatusCode != statusCode {
+				t.Fatalf("expected ErrUnexpectedStatus from %s endpoint for HTTP %d, got: %v", name, statusCode, err)
+			}
+			if !strings.Contains(err.Error(), "unexpected success status code") {
+				t.Fatal("unexpected error message:", err)
+			}
+		}
+	}
+
+	statusCode = http.StatusCreated
+	for name, generate := range endpoints {
+		if err := generate(); err != nil {
+			t.Fatalf("unexpected error from %s endpoint: %v", name, err)
+		}
+	}
+	if errors.Is(ApiOperationError{StatusCode: http.StatusBadRequest}, ErrUnexpectedStatus) {
+		t.Fatal("400 error matches ErrUnexpectedStatus")
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL))
+	_, err := session.GenerateSensorData(context.Background(), testGenerateRequest())
+
+	var rateLimitErr RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatal("expected RateLimitError, got:", err)
+	}
+	if rateLimitErr.RetryAfter != 3*time.Second {
+		t.Fatal("unexpected RetryAfter:", rateLimitErr.RetryAfter)
+	}
+
+	var apiErr ApiOperationError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatal("RateLimitError does not unwrap to ApiOperationError:", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, time.February, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-1":                            0,
+		"Mon, 20 Feb 2023 12:00:30 GMT": 30 * time.Second,
+		"Mon, 20 Feb 2023 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for value, expected := range tests {
+		if v := parseRetryAfter(value, now); v != expected {
+			t.Fatalf("unexpected duration for %q: %s", value, v)
+		}
+	}
+}
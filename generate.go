@@ -1,15 +1,11 @@
 package akamai
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"sync"
 )
 
 // GenerateRequest is the API generation request schema.
@@ -33,8 +29,51 @@ type GenerateRequest struct {
 
 	// BmSz is the current `bm_sz` cookie.
 	//
-	// This is optional for every version excluding `2`.
+	// This is only required for versions that require it according to RequiresBmSz, i.e. `2`.
 	BmSz string `json:"bm_sz,omitempty"`
+
+	// AkBmsc is the current `ak_bmsc` cookie.
+	//
+	// This is optional, and only used for version `1.7`, which some deployments include in the handshake.
+	AkBmsc string `json:"ak_bmsc,omitempty"`
+
+	// AcceptLanguage is the optional Accept-Language header value of the browser, such as `en-US,en;q=0.9`.
+	AcceptLanguage string `json:"acceptLanguage,omitempty"`
+
+	// Timezone is the optional IANA time zone of the browser, such as `America/New_York`.
+	Timezone string `json:"timezone,omitempty"`
+
+	// ScreenResolution is the optional screen resolution of the browser, formatted as `<width>x<height>`,
+	// such as `1920x1080`.
+	ScreenResolution string `json:"screenResolution,omitempty"`
+}
+
+// ErrInvalidGenerateRequest is the error returned by GenerateRequest.Validate if the request is malformed,
+// joined with an error describing why.
+var ErrInvalidGenerateRequest = errors.New("akamai-sdk-go: invalid generate request")
+
+// Validate checks that the request is well-formed: UserAgent must be set, Version must be one of the Version
+// constants, PageURL must be an absolute URL, and BmSz must be set if Version requires it (see RequiresBmSz).
+// It does not check that the user agent is supported; see ValidateUserAgent. A nil request is malformed.
+//
+// The error returned is non-nil if the request is malformed. In this case, the returned error is
+// ErrInvalidGenerateRequest, joined with an error describing why.
+func (req *GenerateRequest) Validate() error {
+	var reason string
+	if req == nil {
+		reason = "nil request"
+	} else if req.UserAgent == "" {
+		reason = "missing user agent"
+	} else if !req.Version.IsKnown() {
+		reason = fmt.Sprintf("unknown version %q", req.Version)
+	} else if u, err := url.Parse(req.PageURL); err != nil || !u.IsAbs() {
+		reason = fmt.Sprintf("page URL %q is not absolute", req.PageURL)
+	} else if RequiresBmSz(req.Version) && req.BmSz == "" {
+		reason = fmt.Sprintf("missing bm_sz cookie for version %s", req.Version)
+	} else {
+		return nil
+	}
+	return errors.Join(ErrInvalidGenerateRequest, errors.New("akamai-sdk-go: "+reason))
 }
 
 // GenerateResponse is the API generation response schema.
@@ -52,46 +91,25 @@ type GenerateResponse struct {
 // When sending POST requests to generate an `_abck` cookie with the generated sensor data,
 // callers SHOULD NOT encode the data as JSON. Akamai Bot Manager sends the payload as JSON,
 // but does not properly encode the data as JSON. Because of this, the request body should be
-// created as such: `{"sensor_data":"` + <generated sensor data> + `"}`.
+// created as such: `{"sensor_data":"` + <generated sensor data> + `"}`, which SensorDataEnvelope builds.
 // Callers using Generate do not need to worry about this requirement as Generate
 // handles this automatically.
+//
+// The request is validated with GenerateRequest.Validate before making any request. The user agent is also
+// validated with ValidateUserAgent, unless the session was created with WithoutUserAgentValidation.
 func (session Session) GenerateSensorData(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
-	encoded, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://akamai.publicapis.solarsystems.software/v1/sensor/generate", bytes.NewBuffer(encoded))
-	if err != nil {
+	if err := req.Validate(); err != nil {
 		return nil, err
 	}
-	request.Header.Set("User-Agent", "SolarSystems akamai-sdk-go")
-	request.Header.Set("x-api-key", session.apiKey)
-	request.Header.Set("Content-Type", "application/json")
-
-	response, err := session.client.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	defer response.Body.Close()
-
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
+	if err := session.validateUserAgent(req.UserAgent); err != nil {
 		return nil, err
 	}
 
-	if response.StatusCode != http.StatusCreated {
-		return nil, ApiOperationError{
-			StatusCode: response.StatusCode,
-			Message:    GetMessageFromErrorResponse(body),
-		}
-	}
-
 	var resp GenerateResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
+	if err := session.doAPIRequest(ctx, sensorEndpoint, req, &resp); err != nil {
 		return nil, err
 	}
-
+	session.observePayload(sensorEndpoint, resp.Payload)
 	return &resp, nil
 }
 
@@ -99,31 +117,97 @@ var (
 	// ErrInvalidPageURL is an error caused by Session.Generate if the provided page URL is not
 	// a valid or absolute URL. An absolute URL must contain a scheme and host.
 	ErrInvalidPageURL = errors.New("akamai-sdk-go: invalid page URL")
+
+	// ErrUnsupportedScheme is an error caused by Session.Generate if the provided page URL is absolute but
+	// its scheme is not http or https. It is joined with an error naming the scheme.
+	ErrUnsupportedScheme = errors.New("akamai-sdk-go: unsupported page URL scheme")
+
+	// ErrScriptNotFound is an error caused by Session.GenerateWithConfig if GenerateConfig.RequireScript
+	// is set and the page does not contain the Akamai Bot Manager web SDK script.
+	ErrScriptNotFound = errors.New("akamai-sdk-go: web SDK script not found")
+
+	// ErrPixelChallengeNotFound is an error caused by Session.GenerateWithConfig if
+	// GenerateConfig.RequirePixelChallenge is set and the page does not contain the pixel challenge.
+	ErrPixelChallengeNotFound = errors.New("akamai-sdk-go: pixel challenge not found")
+
+	// ErrNotHTML is an error caused by Session.GenerateWithConfig if GenerateConfig.Strict is set and
+	// the page does not look like an HTML document. See LooksLikeHTML.
+	ErrNotHTML = errors.New("akamai-sdk-go: page is not an HTML document")
+
+	// ErrUnrecognizedScript is an error caused by Session.GenerateWithConfig if GenerateConfig.Strict is set
+	// and the web SDK script does not match any known version signature. See DetectSdkVersion and
+	// WithVersionDetector.
+	ErrUnrecognizedScript = errors.New("akamai-sdk-go: unrecognized web SDK script")
+
+	// ErrEmptyScript is an error caused by Session.GenerateWithConfig if the web SDK script is fetched
+	// successfully but its body is empty, e.g. because of a CDN failure. Detecting the version of an empty
+	// script would silently fall back to version 1.7.
+	ErrEmptyScript = errors.New("akamai-sdk-go: empty web SDK script")
+
+	// ErrUnknownVersion is an error caused by Session.GenerateWithConfig if GenerateConfig.ForceVersion is
+	// set to a value other than one of the Version constants, or if the detector set with WithVersionDetector
+	// returns such a value.
+	ErrUnknownVersion = errors.New("akamai-sdk-go: unknown web SDK version")
+
+	// ErrPixelPostRejected is an error caused by Session.GenerateWithConfig if GenerateConfig.Strict is set
+	// and the pixel challenge payload POST request responds with a status code outside the 2xx range.
+	// It is joined with a BadStatusCodeError.
+	ErrPixelPostRejected = errors.New("akamai-sdk-go: pixel challenge payload rejected")
+
+	// ErrCookieStillInvalid is an error caused by Session.GenerateWithConfig if GenerateConfig.Strict is set
+	// and the _abck cookie is still clearly invalid after the last sensor data POST, meaning its state is
+	// AbckUnset or AbckInvalid (see ParseAbckState). As websites without the stop signal enabled also use -1
+	// for valid cookies, strict mode should only be used for websites known to enable the stop signal.
+	ErrCookieStillInvalid = errors.New("akamai-sdk-go: _abck cookie still invalid")
 )
 
+// FailFastError is the error returned by Session.GenerateWithConfig if GenerateConfig.FailFast is set and
+// a worker fails. The errors of the other workers, which are typically caused by the cancellation, are joined
+// after it.
+type FailFastError struct {
+	// Worker is the name of the worker that failed first: "pixel", "sec_cpt" or "sensor".
+	Worker string
+
+	// Err is the error of the worker.
+	Err error
+}
+
+func (e FailFastError) Error() string {
+	return fmt.Sprintf("akamai-sdk-go: %s worker failed first: %s", e.Worker, e.Err)
+}
+
+// Unwrap returns the error of the worker.
+func (e FailFastError) Unwrap() error {
+	return e.Err
+}
+
 // Generate generates a set of cookies (_abck, bm_sz, ak_bmsc and possibly others) to use in an HTTP
 // request to an API endpoint protected by Akamai Bot Manager. This method handles all possible scenarios
-// and outcomes of generation for both the Akamai Bot Manager web SDK ("sensor data") and the pixel challenge
-// (if it is present and not solved already). Callers simply have to provide an implementation of DoHttpReqFunc
-// and GetCookieFunc; see their documentation for instructions on how to make custom implementations.
+// and outcomes of generation for the Akamai Bot Manager web SDK ("sensor data"), the pixel challenge
+// (if it is present and not solved already) and, if enabled with GenerateConfig.SolveSecCpt, the sec_cpt
+// challenge (if it is present). Callers simply have to provide an implementation of DoHttpReqFunc and
+// GetCookieFunc; see their documentation for instructions on how to make custom implementations.
 //
 // Generate makes an HTTP GET request to the given page URL and obtains the required variables from the
 // HTML document (pixel challenge script location and web SDK script location). It then makes HTTP requests
-// to both scripts, and sends POST requests containing payloads to generate cookies. The pixel challenge
-// and sensor data generation happens concurrently, meaning the order of the sent requests may not
-// always be the same. Implementations should use a mutex if they need concurrency safety in their implementation
-// of DoHttpReqFunc or GetCookieFunc as both functions can be called by multiple goroutines.
+// to both scripts, and sends POST requests containing payloads to generate cookies. The challenges
+// and sensor data generation happen concurrently, meaning the order of the sent requests may not
+// always be the same (see GenerateConfig.Sequential). Implementations should use a mutex if they need
+// concurrency safety in their implementation of DoHttpReqFunc or GetCookieFunc as both functions can be
+// called by multiple goroutines.
 //
 // Sensor data generation sends a maximum of maxTries requests, after which it gives up. Generation will stop sooner
 // if the website uses the stop signal feature; see IsCookieValid for more information.
 // Websites typically require one POST request with sensor data from the SolarSystems API to generate a valid _abck
 // cookie. Websites with challenges require two. Setting maxTries to two is a reasonable choice.
 //
-// Generate blocks until solving the pixel challenge and generating an _abck is complete. It is safe for usage
+// Generate blocks until solving the challenges and generating an _abck is complete. It is safe for usage
 // by multiple goroutines.
 //
 // Generate panics if doHttpReq or getCookie is nil. pageUrl must also be an absolute URL, and maxTries must be
-// a positive, non-zero integer.
+// a positive, non-zero integer. If userAgent is empty, the default user agent of the session is used; see
+// WithDefaultUserAgent. The user agent is validated before making any request; see GenerateSensorData for
+// more information.
 func (session Session) Generate(
 	ctx context.Context,
 	userAgent,
@@ -132,173 +216,315 @@ func (session Session) Generate(
 	getCookie GetCookieFunc,
 	maxTries int,
 ) error {
-	if doHttpReq == nil {
-		panic("akamai-sdk-go: nil DoHttpReqFunc passed to Generate")
-	}
-	if getCookie == nil {
-		panic("akamai-sdk-go: nil GetCookieFunc passed to Generate")
-	}
+	_, err := session.GenerateWithResult(ctx, userAgent, pageUrl, doHttpReq, getCookie, maxTries)
+	return err
+}
+
+// GenerateResult describes the outcome of a call to Session.GenerateWithResult.
+type GenerateResult struct {
+	// SensorPostCount is the number of POST requests sent with sensor data.
+	SensorPostCount int
+
+	// SensorPayloadBytes are the lengths in bytes of the sensor data payloads generated, one per attempt.
+	SensorPayloadBytes []int
+
+	// SensorMaxTries is the maximum number of POST requests with sensor data generation allowed, which is
+	// GenerateConfig.SensorMaxTries unless it was extended by GenerateConfig.AutoExtendTries. It is zero
+	// if sensor data generation was skipped.
+	SensorMaxTries int
+
+	// PixelChallengePresent reports if the page contains the pixel challenge.
+	PixelChallengePresent bool
+
+	// PixelChallengeCount is the number of pixel challenges the page contains. Pages rarely contain more
+	// than one; see GetAllPixelChallengeScriptURLs.
+	PixelChallengeCount int
+
+	// PixelSolved reports if a pixel challenge payload was posted.
+	PixelSolved bool
+
+	// PixelSolvedCount is the number of pixel challenges a payload was posted for.
+	PixelSolvedCount int
+
+	// PixelPostStatus is the HTTP status code of the last pixel challenge payload POST request, or zero if
+	// none was sent. If the page contains multiple pixel challenges, a status code outside the 2xx range
+	// takes precedence.
+	PixelPostStatus int
+
+	// PixelPayloadBytes is the total length in bytes of the pixel challenge payloads generated. It is zero
+	// if no payload was generated.
+	PixelPayloadBytes int
+
+	// PixelAlreadySolved reports if a pixel challenge is present but was already solved, in which case
+	// no payload is posted for it. See IsPixelAlreadySolved.
+	PixelAlreadySolved bool
+
+	// SecCptChallengePresent reports if the page contains the sec_cpt challenge.
+	SecCptChallengePresent bool
+
+	// SecCptSolved reports if a sec_cpt challenge payload was posted.
+	SecCptSolved bool
+
+	// StoppedEarly reports if sensor data generation stopped because the stop signal
+	// reported the _abck cookie as valid. See IsCookieValid for more information.
+	StoppedEarly bool
+
+	// FinalCookieLikelyValid reports if the _abck cookie is valid after the last sensor data POST according
+	// to the stop signal; see IsCookieValid. Because not all websites enable the stop signal, false does not
+	// mean that the cookie is invalid, only that its validity cannot be confirmed client-side. It is always
+	// false if no sensor data was posted.
+	FinalCookieLikelyValid bool
+
+	// DetectedVersion is the Akamai Bot Manager web SDK version detected from the SDK script, or
+	// GenerateConfig.ForceVersion if it is set. It is empty if the page does not contain the SDK script.
+	DetectedVersion Version
+
+	// SensorPostURL is the absolute URL sensor data was posted to, or would have been posted to in a dry run.
+	// It is empty if the page does not contain the SDK script. Callers can cache it along with
+	// DetectedVersion to refresh the _abck cookie later with Session.RefreshSensor.
+	SensorPostURL string
+
+	// DryRun reports if generation was a dry run (see GenerateConfig.DryRun). If true, no payloads were
+	// generated or posted, and PixelSolved, SecCptSolved and SensorPostCount are always zero values.
+	DryRun bool
+
+	// Cookies are the values of the Akamai Bot Manager cookies (see AkamaiCookieNames) and the pixel
+	// cookie (see GenerateConfig.PixelCookie) once generation is complete, keyed by name.
+	// Cookies that are not set are omitted.
+	Cookies map[string]string
+}
+
+// AkamaiCookieNames are the names of the cookies set by Akamai Bot Manager and its challenges.
+var AkamaiCookieNames = []string{"_abck", "bm_sz", "ak_bmsc", "sbsd", "sec_cpt"}
+
+// GenerateWithResult is like Generate, but also returns a GenerateResult describing how generation went.
+//
+// If a worker fails, e.g. the pixel challenge cannot be solved, the other workers still run to completion
+// (unless GenerateConfig.FailFast is set), so the returned result is non-nil along with the error and
+// describes what succeeded, including the cookies. Callers can then decide if the partial result is usable,
+// e.g. if GenerateResult.FinalCookieLikelyValid is true. The returned result is nil if generation fails
+// before the workers start, e.g. because the page cannot be fetched.
+func (session Session) GenerateWithResult(
+	ctx context.Context,
+	userAgent,
+	pageUrl string,
+	doHttpReq DoHttpReqFunc,
+	getCookie GetCookieFunc,
+	maxTries int,
+) (*GenerateResult, error) {
 	if maxTries <= 0 {
 		panic("akamai-sdk-go: maxTries <= 0")
 	}
 
-	// We don't need the parsed URL until later, but we parse it now to ensure it's valid and absolute.
-	// This will avoid wasting a request if it's invalid.
-	u, err := url.Parse(pageUrl)
+	cfg := DefaultGenerateConfig()
+	cfg.SensorMaxTries = maxTries
+	return session.GenerateWithConfig(ctx, userAgent, pageUrl, doHttpReq, getCookie, cfg)
+}
+
+// GenerateWithConfig is like GenerateWithResult, but uses the given GenerateConfig instead of maxTries.
+// See Generate for more information.
+//
+// GenerateWithConfig panics if doHttpReq or getCookie is nil, or if cfg.SensorMaxTries <= 0.
+func (session Session) GenerateWithConfig(
+	ctx context.Context,
+	userAgent,
+	pageUrl string,
+	doHttpReq DoHttpReqFunc,
+	getCookie GetCookieFunc,
+	cfg GenerateConfig,
+) (*GenerateResult, error) {
+	userAgent = session.resolveUserAgent(userAgent)
+	u, err := session.checkGenerateArgs(userAgent, pageUrl, doHttpReq, getCookie, cfg)
 	if err != nil {
-		return err
-	}
-	if !u.IsAbs() {
-		return ErrInvalidPageURL
+		return nil, err
 	}
+	doHttpReq = session.observeHttpReq(withReqContext(doHttpReq, pageUrl, cfg.MaxBodyBytes))
 
 	// GET pageUrl
-	statusCode, pageBody, err := doHttpReq(ctx, OpGetPage, pageUrl, http.MethodGet, nil)
+	spanCtx, endSpan := session.startSpan(ctx, SpanGetPage)
+	statusCode, pageBody, err := doHttpReq(spanCtx, OpGetPage, pageUrl, http.MethodGet, nil)
 	if err == nil && statusCode != http.StatusOK {
 		err = BadStatusCodeError{StatusCode: statusCode}
 	}
+	endSpan(err)
 	if err != nil {
-		return errors.Join(HttpOpError{Op: OpGetPage}, err)
+		err = errors.Join(HttpOpError{Op: OpGetPage}, err)
+		if cfg.OnEvent != nil {
+			cfg.OnEvent(GenerateEvent{Type: EventDone, Err: err})
+		}
+		return nil, err
 	}
-
-	// wg is the WaitGroup for all worker goroutines.
-	var wg sync.WaitGroup
-	wg.Add(2)
-	// errs are the errors reported by the workers.
-	var errs []error
-	var mu sync.Mutex
-	// addError appends to errs. It is safe for usage by multiple goroutines.
-	addError := func(err error) {
-		mu.Lock()
-		errs = append(errs, err)
-		mu.Unlock()
+	session.debugf("akamai-sdk-go: fetched page %s (%d bytes)", pageUrl, len(pageBody))
+	if cfg.OnEvent != nil {
+		cfg.OnEvent(GenerateEvent{Type: EventPageFetched})
 	}
 
-	// Solve pixel challenge
-	go func() {
-		defer wg.Done()
-
-		// Get the script's URL and the URL to post the payload to
-		ok, scriptUrl, postUrl := GetPixelChallengeScriptURL(pageBody)
-		if !ok {
-			// Pixel challenge is not present on this page.
-			return
-		}
-
-		// Get the HTML variable
-		htmlVar, err := GetPixelChallengeHtmlVar(pageBody)
-		if err != nil {
-			addError(err)
-			return
-		}
+	return session.generateFromPage(ctx, userAgent, pageUrl, u, pageBody, doHttpReq, getCookie, cfg)
+}
 
-		// GET request to pixel script
-		statusCode, scriptBody, err := doHttpReq(ctx, OpGetPixelChallengeScript, scriptUrl, http.MethodGet, nil)
-		if err == nil && statusCode != http.StatusOK {
-			if statusCode == http.StatusNotFound {
-				// Pixel challenge script returns 404 when the challenge is already solved.
-				return
-			}
+// GenerateFromPage is like Generate, but uses the given page body instead of making an HTTP GET request
+// to pageUrl. This avoids a request for callers that already fetched the page, for example in an earlier
+// step of a scraping pipeline. pageUrl must still be the absolute URL the page was fetched from, as it is
+// used to resolve the script URLs and read cookies.
+//
+// GenerateFromPage panics under the same conditions as Generate.
+func (session Session) GenerateFromPage(
+	ctx context.Context,
+	userAgent,
+	pageUrl string,
+	pageBody []byte,
+	doHttpReq DoHttpReqFunc,
+	getCookie GetCookieFunc,
+	maxTries int,
+) error {
+	if maxTries <= 0 {
+		panic("akamai-sdk-go: maxTries <= 0")
+	}
 
-			err = BadStatusCodeError{StatusCode: statusCode}
-		}
-		if err != nil {
-			addError(err)
-			return
-		}
+	cfg := DefaultGenerateConfig()
+	cfg.SensorMaxTries = maxTries
+	userAgent = session.resolveUserAgent(userAgent)
+	u, err := session.checkGenerateArgs(userAgent, pageUrl, doHttpReq, getCookie, cfg)
+	if err != nil {
+		return err
+	}
 
-		// Get dynamic script variable
-		scriptVar, err := GetPixelChallengeScriptVar(scriptBody)
-		if err != nil {
-			addError(err)
-			return
-		}
+	doHttpReq = session.observeHttpReq(withReqContext(doHttpReq, pageUrl, cfg.MaxBodyBytes))
+	_, err = session.generateFromPage(ctx, userAgent, pageUrl, u, pageBody, doHttpReq, getCookie, cfg)
+	return err
+}
 
-		// Generate payload
-		response, err := session.GeneratePixelPayload(ctx, &PixelSolveRequest{
-			UserAgent: userAgent,
-			HtmlVar:   htmlVar,
-			ScriptVar: scriptVar,
-		})
-		if err != nil {
-			addError(err)
-			return
-		}
+// RefreshSensor is like Generate, but only generates and posts sensor data to obtain a fresh _abck cookie,
+// using the sensor data URL and web SDK version discovered by a previous call to GenerateWithResult
+// (see GenerateResult.SensorPostURL and GenerateResult.DetectedVersion). The page and the web SDK script are
+// not fetched and the challenges are not solved again, which saves requests when refreshing the cookies of
+// a session that already loaded the page. Callers should cache both values per page; they rarely change,
+// but callers should fall back to Generate if refreshing fails repeatedly.
+//
+// sensorPostUrl may be relative to pageUrl. A missing or malformed bm_sz cookie is still refreshed if
+// enabled with WithBmSzRefresh. The returned GenerateResult only describes sensor data generation.
+//
+// The error returned is non-nil under the same conditions as Generate, or if version is not known, in
+// which case it is ErrUnknownVersion.
+//
+// RefreshSensor panics under the same conditions as Generate.
+func (session Session) RefreshSensor(
+	ctx context.Context,
+	userAgent,
+	pageUrl,
+	sensorPostUrl string,
+	version Version,
+	doHttpReq DoHttpReqFunc,
+	getCookie GetCookieFunc,
+	maxTries int,
+) (*GenerateResult, error) {
+	if maxTries <= 0 {
+		panic("akamai-sdk-go: maxTries <= 0")
+	}
 
-		// POST payload
-		if _, _, err = doHttpReq(
-			ctx,
-			OpPostPixelPayload,
-			postUrl,
-			http.MethodPost,
-			bytes.NewBufferString(response.Payload),
-		); err != nil {
-			addError(err)
-			return
-		}
-	}()
+	cfg := DefaultGenerateConfig()
+	cfg.SensorMaxTries = maxTries
+	userAgent = session.resolveUserAgent(userAgent)
+	u, err := session.checkGenerateArgs(userAgent, pageUrl, doHttpReq, getCookie, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !version.IsKnown() {
+		return nil, ErrUnknownVersion
+	}
+	if sensorPostUrl, err = AbsolutizeURL(u, sensorPostUrl); err != nil {
+		return nil, err
+	}
 
-	// Generate _abck
-	go func() {
-		defer wg.Done()
+	g := generation{
+		session:   session,
+		ctx:       ctx,
+		cfg:       cfg,
+		userAgent: userAgent,
+		pageUrl:   pageUrl,
+		u:         u,
+		doHttpReq: session.observeHttpReq(withReqContext(doHttpReq, pageUrl, cfg.MaxBodyBytes)),
+		getCookie: getCookie,
+	}
+	g.result.DetectedVersion = version
+	g.result.SensorPostURL = sensorPostUrl
+	if err = g.refreshMalformedBmSz(version); err == nil {
+		err = g.postSensorDataUntilValid(version, sensorPostUrl)
+	}
+	g.collectCookies()
+	return &g.result, err
+}
 
-		// Get script path
-		ok, scriptPath := GetScriptPath(pageBody)
-		if !ok {
-			// If there's no script path on the page then we skip generating.
-			return
-		}
-		// Construct script URL -- scriptPath will always begin with a /
-		scriptUrl := fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, scriptPath)
+// checkGenerateArgs validates the arguments shared by all Generate variants and returns the parsed page URL.
+// It panics if doHttpReq or getCookie is nil, or if cfg.SensorMaxTries <= 0.
+func (session Session) checkGenerateArgs(
+	userAgent,
+	pageUrl string,
+	doHttpReq DoHttpReqFunc,
+	getCookie GetCookieFunc,
+	cfg GenerateConfig,
+) (*url.URL, error) {
+	if doHttpReq == nil {
+		panic("akamai-sdk-go: nil DoHttpReqFunc passed to Generate")
+	}
+	if getCookie == nil {
+		panic("akamai-sdk-go: nil GetCookieFunc passed to Generate")
+	}
+	if cfg.SensorMaxTries <= 0 {
+		panic("akamai-sdk-go: SensorMaxTries <= 0")
+	}
+	if cfg.ForceVersion != "" && !cfg.ForceVersion.IsKnown() {
+		return nil, ErrUnknownVersion
+	}
 
-		// GET request to script
-		statusCode, scriptBody, err := doHttpReq(ctx, OpGetSdkScript, scriptUrl, http.MethodGet, nil)
-		if err == nil && statusCode != http.StatusOK {
-			err = BadStatusCodeError{StatusCode: statusCode}
-		}
-		if err != nil {
-			addError(err)
-			return
-		}
+	// We don't need the parsed URL until later, but we parse it now to ensure it's valid and absolute.
+	// This will avoid wasting a request if it's invalid.
+	u, err := url.Parse(pageUrl)
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, ErrInvalidPageURL
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Join(ErrUnsupportedScheme, fmt.Errorf("scheme: %s", u.Scheme))
+	}
+	if err = session.validateUserAgent(userAgent); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
 
-		// Get SDK version
-		version := GetSdkVersion(scriptBody)
-
-		// Generate and post sensor data
-		for i := 0; i < maxTries; i++ {
-			request := GenerateRequest{
-				UserAgent: userAgent,
-				Version:   version,
-				PageURL:   pageUrl,
-				Abck:      getCookie(u, "_abck"),
-			}
-			if version == Version2 {
-				request.BmSz = getCookie(u, "bm_sz")
-			}
-
-			response, err := session.GenerateSensorData(ctx, &request)
-			if err != nil {
-				addError(err)
-				return
-			}
-
-			if _, _, err = doHttpReq(
-				ctx,
-				OpPostSensorData,
-				scriptUrl,
-				http.MethodPost,
-				bytes.NewBufferString(fmt.Sprintf(`{"sensor_data":"%s"}`, response.Payload)),
-			); err != nil {
-				addError(err)
-				return
-			}
-
-			if IsCookieValid(getCookie(u, "_abck"), i) {
-				break
-			}
-		}
-	}()
+// generateFromPage runs generation for the given page body. doHttpReq must already be observed.
+func (session Session) generateFromPage(
+	ctx context.Context,
+	userAgent,
+	pageUrl string,
+	u *url.URL,
+	pageBody []byte,
+	doHttpReq DoHttpReqFunc,
+	getCookie GetCookieFunc,
+	cfg GenerateConfig,
+) (*GenerateResult, error) {
+	g := generation{
+		session:   session,
+		ctx:       ctx,
+		cfg:       cfg,
+		userAgent: userAgent,
+		pageUrl:   pageUrl,
+		u:         u,
+		pageBody:  pageBody,
+		doHttpReq: doHttpReq,
+		getCookie: getCookie,
+	}
+	if cfg.Strict && !LooksLikeHTML(pageBody) {
+		g.emit(GenerateEvent{Type: EventDone, Err: ErrNotHTML})
+		return nil, ErrNotHTML
+	}
 
-	wg.Wait()
-	return errors.Join(errs...)
+	err := g.run()
+	g.emit(GenerateEvent{Type: EventDone, Err: err})
+	g.result.DryRun = cfg.DryRun
+	g.collectCookies()
+	return &g.result, err
 }
@@ -0,0 +1,78 @@
+package akamai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// maxBatchConcurrency is the maximum number of concurrent API requests made by GenerateSensorDataBatch.
+const maxBatchConcurrency = 8
+
+// BatchError is an error caused by Session.GenerateSensorDataBatch if at least one request failed.
+type BatchError struct {
+	// Errs are the errors of each request, in the same order as the requests.
+	// Errs[i] is nil if the i-th request succeeded.
+	Errs []error
+}
+
+func (e BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errs {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("akamai-sdk-go: %d of %d batch requests failed; first error: %s", failed, len(e.Errs), first)
+}
+
+// Unwrap returns the non-nil errors of the failed requests.
+func (e BatchError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// GenerateSensorDataBatch generates sensor data for each of the given requests. See GenerateSensorData.
+//
+// The requests are currently sent concurrently (at most eight at a time) rather than as a single
+// API request. The returned responses are in the same order as the requests; the response of a failed
+// request is nil. If at least one request failed, the returned error is a BatchError.
+func (session Session) GenerateSensorDataBatch(
+	ctx context.Context,
+	reqs []*GenerateRequest,
+) ([]*GenerateResponse, error) {
+	responses := make([]*GenerateResponse, len(reqs))
+	errs := make([]error, len(reqs))
+
+	slots := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, req *GenerateRequest) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			responses[i], errs[i] = session.GenerateSensorData(ctx, req)
+		}(i, req)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return responses, BatchError{Errs: errs}
+		}
+	}
+	return responses, nil
+}
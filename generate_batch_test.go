@@ -0,0 +1,53 @@
+package akamai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGenerateSensorDataBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.PageURL == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(GenerateResponse{Payload: req.PageURL})
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL))
+	reqs := make([]*GenerateRequest, 20)
+	for i := range reqs {
+		reqs[i] = &GenerateRequest{UserAgent: testUserAgent, Version: Version175, PageURL: testPageURL + "/" + string(rune('a'+i))}
+	}
+	reqs[5].PageURL = ""
+
+	responses, err := session.GenerateSensorDataBatch(context.Background(), reqs)
+	var batchErr BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatal("expected BatchError, got:", err)
+	}
+	for i, req := range reqs {
+		if i == 5 {
+			if responses[i] != nil || batchErr.Errs[i] == nil {
+				t.Fatal("expected failed request 5")
+			}
+			continue
+		}
+
+		if batchErr.Errs[i] != nil {
+			t.Fatal("unexpected error:", batchErr.Errs[i])
+		}
+		if responses[i].Payload != req.PageURL {
+			t.Fatal("responses out of order:", i, responses[i].Payload)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package akamai
+
+import (
+	"net/url"
+	"time"
+)
+
+// GenerateConfig configures a call to Session.GenerateWithConfig.
+// Callers should start from DefaultGenerateConfig and override the fields they need.
+type GenerateConfig struct {
+	// SensorMaxTries is the maximum number of POST requests with sensor data, after which sensor data
+	// generation gives up. It must be positive. See Session.Generate for more information.
+	SensorMaxTries int
+
+	// AutoExtendTries extends SensorMaxTries up to the stop signal threshold of the _abck cookie (see
+	// ParseStopSignal) when the threshold shows that more POST requests are needed for a valid cookie,
+	// instead of giving up short. The number of tries is never extended past eight, which bounds the
+	// API credits used. See GenerateResult.SensorMaxTries.
+	AutoExtendTries bool
+
+	// PixelMaxTries is the maximum number of POST requests with the pixel challenge payload.
+	// The payload is only posted again if PixelCookie is set and no cookie with that name exists after posting.
+	// Posting the payload again does not use additional API credits. Values less than one are treated as one.
+	PixelMaxTries int
+
+	// PixelCookie is the name of the cookie the website sets once the pixel challenge is solved.
+	// It is only used to decide whether to post the pixel challenge payload again; see PixelMaxTries.
+	PixelCookie string
+
+	// PixelPostURLFunc derives the URL to post the pixel challenge payload to from the pixel challenge
+	// script URL. If nil, PixelPostURL is used. This allows callers to adapt to deployments using a
+	// different convention.
+	PixelPostURLFunc func(scriptUrl string) string
+
+	// RequireScript makes generation fail with ErrScriptNotFound if the page does not contain the
+	// Akamai Bot Manager web SDK script. By default, sensor data generation is silently skipped.
+	RequireScript bool
+
+	// RequirePixelChallenge makes generation fail with ErrPixelChallengeNotFound if the page does not
+	// contain the pixel challenge. By default, solving the pixel challenge is silently skipped.
+	RequirePixelChallenge bool
+
+	// SolveSecCpt enables solving the sec_cpt challenge (see GetSecCptChallenge) with
+	// Session.GenerateSecCptPayload. Support for the challenge is experimental, so it is disabled by default:
+	// generation then only reports the challenge in GenerateResult.SecCptChallengePresent.
+	SolveSecCpt bool
+
+	// Strict enables additional sanity checks that make generation fail instead of silently producing
+	// an invalid cookie. In strict mode, generation fails with:
+	//   - ErrNotHTML if the page does not look like an HTML document according to LooksLikeHTML;
+	//   - ErrUnrecognizedScript if the web SDK script does not match any known version signature;
+	//   - ErrCookieStillInvalid if the _abck cookie is still clearly invalid after posting sensor data;
+	//   - ErrPixelPostRejected if a pixel challenge payload POST request responds with a non-2xx status code.
+	Strict bool
+
+	// DryRun makes generation fetch the page and scripts and parse them as usual, but skip all
+	// SolarSystems API requests and the POST requests that would send their payloads. This allows
+	// callers to verify their DoHttpReqFunc and GetCookieFunc and detect parsing problems on live pages
+	// without using API credits. See GenerateResult.DryRun.
+	DryRun bool
+
+	// SensorContentType is the Content-Type of the sensor data POST requests, passed to the DoHttpReqFunc
+	// as ReqContext.ContentType (see ReqContextFromContext). Implementations already choose headers based
+	// on the HttpReqOp, so this is only needed when the body shape is changed with SensorBodyFunc.
+	SensorContentType string
+
+	// SensorBodyFunc builds the body of the sensor data POST requests from the generated payload, for websites
+	// expecting e.g. a raw body. If nil, SensorDataEnvelope is used.
+	SensorBodyFunc func(payload string) []byte
+
+	// InlineSensorPostURL is the URL to post sensor data to when the page inlines the web SDK script in a
+	// <script> tag instead of referencing it (see GetInlineScript). Scripts recognized by the VersionDetectorFunc
+	// of the session (see WithVersionDetector) are also treated as inline web SDK scripts. Websites normally
+	// post sensor data to the URL of the script, which an inline script does not have, so it must be found by
+	// inspecting the requests of a browser. It may be absolute or relative to the page URL. If empty, inline
+	// scripts are ignored and the page is treated as not containing the web SDK script.
+	InlineSensorPostURL string
+
+	// SensorPostURLFunc returns the URL to post sensor data to, given the absolute URL of the web SDK script
+	// (or InlineSensorPostURL resolved against the page URL, for inline scripts) and the page URL. It is
+	// called once per generation, after the script is found. If nil, sensor data is posted to the script URL,
+	// which is what websites normally do, or to the endpoint embedded in the script if DetectSensorEndpoint
+	// is set.
+	SensorPostURLFunc func(scriptUrl string, pageUrl *url.URL) string
+
+	// DetectSensorEndpoint makes generation post sensor data to the endpoint embedded in the web SDK script,
+	// resolved against the page URL, if one is found (see GetSensorEndpoint), instead of to the script URL.
+	// The endpoint is recognized heuristically and may be wrong for scripts that post to their own URL, so
+	// it is disabled by default. It is ignored if SensorPostURLFunc is set.
+	DetectSensorEndpoint bool
+
+	// MaxBodyBytes is the maximum size in bytes of the response bodies returned by the DoHttpReqFunc, e.g. the
+	// page and the web SDK script. Larger bodies cause generation to fail with ErrBodyTooLarge. Reading the
+	// bodies is the responsibility of the DoHttpReqFunc, so implementations should stop reading early using
+	// ReqContext.MaxBodyBytes and LimitedReadAll, like the one returned by NewHTTPDoer does. If zero, there
+	// is no limit.
+	MaxBodyBytes int64
+
+	// ForceVersion is the Akamai Bot Manager web SDK version to generate sensor data for. If set, the web SDK
+	// script is not fetched and its version is not detected, which saves a request for websites known to use
+	// a specific version. The version is not verified, so sensor data generation fails silently if the website
+	// uses a different version. It must be one of the Version constants, otherwise generation fails with
+	// ErrUnknownVersion.
+	ForceVersion Version
+
+	// OnEvent is called with the milestones of generation, e.g. to report progress to a user interface.
+	// Events are emitted by the concurrent workers, so their order is not guaranteed across workers, but
+	// calls to OnEvent never overlap and EventDone is always the last event. OnEvent should return quickly,
+	// as it blocks the worker emitting the event. If nil, no events are emitted.
+	OnEvent func(event GenerateEvent)
+
+	// FailFast cancels the pixel challenge, sec_cpt challenge and sensor data workers as soon as one of them
+	// fails, aborting their pending API calls and HTTP requests instead of letting them run to completion.
+	// The returned error then starts with a FailFastError identifying the worker that failed first. The
+	// HTTP requests of all workers are made with a shared context that is cancelled on failure, so in-flight
+	// requests are only aborted if the DoHttpReqFunc honors ctx cancellation.
+	FailFast bool
+
+	// SensorPayloadRetries is the number of times a sensor data payload failing ValidateSensorPayload is
+	// generated again before giving up, instead of posting it. Each regenerated payload costs an API call,
+	// but saves a POST request and sensor data try. If generation gives up, it fails with
+	// ErrInvalidSensorPayload. If zero, payloads are not validated.
+	SensorPayloadRetries int
+
+	// InterPostDelay is the delay between consecutive sensor data tries, as browsers post sensor data
+	// again only after user interaction, and back-to-back POST requests are a bot signal on strict websites.
+	// A random delay of up to InterPostJitter is added to it. The delay is waited for with the Clock of the
+	// session if it implements ClockTimer, and ends early if the context is cancelled. If both are zero,
+	// sensor data is posted again immediately, which is the default.
+	InterPostDelay  time.Duration
+	InterPostJitter time.Duration
+
+	// Sequential solves the pixel challenges, then the sec_cpt challenge, and only then generates sensor
+	// data, instead of doing all of it concurrently. The requests are then always made in the same order,
+	// which makes traffic captures reproducible at the cost of a slower generation. If FailFast is also
+	// set, the steps after a failed step are skipped.
+	Sequential bool
+
+	// AcceptLanguage, Timezone and ScreenResolution are optional fingerprint hints sent with every sensor
+	// data generation request; see the GenerateRequest fields of the same name. Empty values are omitted,
+	// and the API ignores hints it does not support.
+	AcceptLanguage   string
+	Timezone         string
+	ScreenResolution string
+}
+
+// maxAutoExtendedSensorTries is the maximum number of sensor data POST requests with
+// GenerateConfig.AutoExtendTries set.
+const maxAutoExtendedSensorTries = 8
+
+// DefaultGenerateConfig returns the GenerateConfig used by Session.Generate with a maxTries of two.
+func DefaultGenerateConfig() GenerateConfig {
+	return GenerateConfig{
+		SensorMaxTries: 2,
+		PixelMaxTries:  1,
+	}
+}
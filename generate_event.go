@@ -0,0 +1,70 @@
+package akamai
+
+// GenerateEventType is the type of a GenerateEvent.
+type GenerateEventType byte
+
+func (t GenerateEventType) String() string {
+	switch t {
+	case EventPageFetched:
+		return "EventPageFetched"
+	case EventScriptFetched:
+		return "EventScriptFetched"
+	case EventSensorPosted:
+		return "EventSensorPosted"
+	case EventPixelPosted:
+		return "EventPixelPosted"
+	case EventDone:
+		return "EventDone"
+	default:
+		return ""
+	}
+}
+
+const (
+	// EventPageFetched is emitted once the page is fetched. It is not emitted by Session.GenerateFromPage.
+	EventPageFetched GenerateEventType = iota
+
+	// EventScriptFetched is emitted once the web SDK script is fetched.
+	EventScriptFetched
+
+	// EventSensorPosted is emitted after each POST request with sensor data.
+	// GenerateEvent.Attempt and GenerateEvent.Valid are set.
+	EventSensorPosted
+
+	// EventPixelPosted is emitted after each POST request with the pixel challenge payload.
+	// GenerateEvent.Attempt is set.
+	EventPixelPosted
+
+	// EventDone is emitted last, once generation is complete or failed. GenerateEvent.Err is set
+	// to the error returned by generation, if any.
+	EventDone
+)
+
+// GenerateEvent describes a milestone of generation, reported to GenerateConfig.OnEvent.
+// Only the fields documented for its Type are set.
+type GenerateEvent struct {
+	// Type is the type of the event.
+	Type GenerateEventType
+
+	// Attempt is the number of the POST request, starting at one.
+	Attempt int
+
+	// Valid reports if the _abck cookie is valid after the POST request according to the stop signal.
+	// See IsCookieValid.
+	Valid bool
+
+	// Err is the error generation failed with.
+	Err error
+}
+
+// emit calls GenerateConfig.OnEvent with the given event, if it is set. It is safe for usage by
+// multiple goroutines; calls to OnEvent never overlap.
+func (g *generation) emit(event GenerateEvent) {
+	if g.cfg.OnEvent == nil {
+		return
+	}
+
+	g.eventMu.Lock()
+	defer g.eventMu.Unlock()
+	g.cfg.OnEvent(event)
+}
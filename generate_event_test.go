@@ -0,0 +1,54 @@
+package akamai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGenerateOnEvent(t *testing.T) {
+	session, _ := newTestSession(t)
+	browser := &testBrowser{abckCookies: []string{testInvalidAbck, testValidAbck}}
+
+	var events []GenerateEvent
+	cfg := DefaultGenerateConfig()
+	cfg.SensorMaxTries = 3
+	cfg.OnEvent = func(event GenerateEvent) {
+		events = append(events, event)
+	}
+	if _, err := session.GenerateWithConfig(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		cfg,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) == 0 || events[0].Type != EventPageFetched {
+		t.Fatal("expected EventPageFetched first, got:", events)
+	}
+	if last := events[len(events)-1]; last.Type != EventDone || last.Err != nil {
+		t.Fatal("expected EventDone without error last, got:", last)
+	}
+
+	var sensorPosts []GenerateEvent
+	counts := make(map[GenerateEventType]int)
+	for _, event := range events {
+		counts[event.Type]++
+		if event.Type == EventSensorPosted {
+			sensorPosts = append(sensorPosts, event)
+		}
+	}
+	if counts[EventScriptFetched] != 1 || counts[EventPixelPosted] != 1 || counts[EventDone] != 1 {
+		t.Fatal("unexpected event counts:", counts)
+	}
+	expected := []GenerateEvent{
+		{Type: EventSensorPosted, Attempt: 1},
+		{Type: EventSensorPosted, Attempt: 2, Valid: true},
+	}
+	if len(sensorPosts) != len(expected) || sensorPosts[0] != expected[0] || sensorPosts[1] != expected[1] {
+		t.Fatal("unexpected sensor data events:", sensorPosts)
+	}
+}
@@ -1,13 +1,24 @@
 package akamai
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestGenerate tests the Session.Generate method and serves an example of how to design your own custom
@@ -67,3 +78,1134 @@ func TestGenerate(t *testing.T) {
 		panic(err)
 	}
 }
+
+const (
+	testUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0.0.0 Safari/537.36"
+	testPageURL   = "https://www.example.com/product"
+
+	testPageBody = `<html>
+<head>
+<script type="text/javascript"  src="/aBc-dEf/gHi">
+</head>
+<body>
+<noscript><img src="https://www.example.com/akam/13/pixel_1a2b3c?a=dD0xNjc2OTAz" style="visibility: hidden; position: absolute; left: -999px; top: -999px;" /></noscript>
+<script type="text/javascript">bazadebezolkohpepadr="1234"</script>
+<script type="text/javascript" src="https://www.example.com/akam/13/1a2b3c" defer></script>
+</body>
+</html>`
+
+	testSdkScript   = `var _acxj=[];`
+	testPixelScript = `var _=["\x61\x62\x63","\x64\x65\x66"];g=_[1];`
+
+	testValidAbck   = `0C8A2251CC04F60F59160D6AD92DA8A0~0~YAAQlivJF6o1GjGGAQAA~-1~-1~-1`
+	testInvalidAbck = `854B24C98DF862FDB9DCD7A8D317E790~-1~YAAQD9EuF64U3i+GAQAA~-1~-1~-1`
+)
+
+// testGenerateRequest returns a valid GenerateRequest.
+func testGenerateRequest() *GenerateRequest {
+	return &GenerateRequest{UserAgent: testUserAgent, Version: Version175, PageURL: testPageURL}
+}
+
+// testAPI is a fake SolarSystems API counting the requests made to each endpoint.
+type testAPI struct {
+	sensorCalls atomic.Int32
+	pixelCalls  atomic.Int32
+
+	mu sync.Mutex
+	// sensorRequests are the requests made to the sensor endpoint, in order.
+	sensorRequests []GenerateRequest
+}
+
+// newTestSession creates a Session using a fake SolarSystems API. The options are applied after
+// the base URL option.
+func newTestSession(t *testing.T, options ...SessionOption) (Session, *testAPI) {
+	api := &testAPI{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case sensorEndpoint.path:
+			api.sensorCalls.Add(1)
+
+			var req GenerateRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			api.mu.Lock()
+			api.sensorRequests = append(api.sensorRequests, req)
+			api.mu.Unlock()
+		case pixelEndpoint.path:
+			api.pixelCalls.Add(1)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewSessionWithOptions("", append([]SessionOption{WithBaseURL(server.URL)}, options...)...), api
+}
+
+// testBrowser is a fake website and cookie jar used to test Session.Generate.
+type testBrowser struct {
+	mu sync.Mutex
+
+	// page is the page body. If empty, testPageBody is used.
+	page string
+
+	// script is the web SDK script body. If empty, testSdkScript is used.
+	script string
+
+	// pixelStatus is the status code of the pixel challenge script. If zero, 200 is used.
+	pixelStatus int
+
+	// pixelPostStatus is the status code of pixel challenge payload POST requests. If zero, 200 is used.
+	pixelPostStatus int
+
+	// ops are the operations executed with doHttpReq, in order.
+	ops []HttpReqOp
+
+	// urls are the request URLs of ops.
+	urls []string
+
+	// cookies is the cookie jar.
+	cookies map[string]string
+
+	// abckCookies are the _abck cookies set by each sensor data POST, in order.
+	// Once exhausted, the _abck cookie remains unchanged.
+	abckCookies []string
+
+	// bmSzCookies are the bm_sz cookies set by each sensor data POST, like abckCookies.
+	bmSzCookies []string
+
+	// sensorBodies and sensorContentTypes are the bodies and content types of the sensor data POST requests,
+	// in order.
+	sensorBodies       []string
+	sensorContentTypes []string
+}
+
+func (b *testBrowser) doHttpReq(
+	ctx context.Context,
+	op HttpReqOp,
+	requestUrl,
+	_ string,
+	requestBody io.Reader,
+) (statusCode int, responseBody []byte, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.ops = append(b.ops, op)
+	b.urls = append(b.urls, requestUrl)
+	switch op {
+	case OpGetPage:
+		if b.page == "" {
+			return http.StatusOK, []byte(testPageBody), nil
+		}
+		return http.StatusOK, []byte(b.page), nil
+	case OpGetSdkScript:
+		if b.script == "" {
+			return http.StatusOK, []byte(testSdkScript), nil
+		}
+		return http.StatusOK, []byte(b.script), nil
+	case OpPostSensorData:
+		var body []byte
+		if requestBody != nil {
+			body, _ = io.ReadAll(requestBody)
+		}
+		rc, _ := ReqContextFromContext(ctx)
+		b.sensorBodies = append(b.sensorBodies, string(body))
+		b.sensorContentTypes = append(b.sensorContentTypes, rc.ContentType)
+		if b.cookies == nil {
+			b.cookies = make(map[string]string)
+		}
+		if len(b.abckCookies) > 0 {
+			b.cookies["_abck"] = b.abckCookies[0]
+			b.abckCookies = b.abckCookies[1:]
+		}
+		if len(b.bmSzCookies) > 0 {
+			b.cookies["bm_sz"] = b.bmSzCookies[0]
+			b.bmSzCookies = b.bmSzCookies[1:]
+		}
+		return http.StatusCreated, []byte{}, nil
+	case OpGetPixelChallengeScript:
+		if b.pixelStatus != 0 && b.pixelStatus != http.StatusOK {
+			return b.pixelStatus, []byte{}, nil
+		}
+		return http.StatusOK, []byte(testPixelScript), nil
+	case OpPostPixelPayload:
+		if b.pixelPostStatus != 0 {
+			return b.pixelPostStatus, []byte{}, nil
+		}
+		return http.StatusOK, []byte{}, nil
+	default:
+		return http.StatusOK, []byte{}, nil
+	}
+}
+
+func (b *testBrowser) getCookie(_ *url.URL, name string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.cookies[name]
+}
+
+// requests returns the URLs of the requests made for op, in order.
+func (b *testBrowser) requests(op HttpReqOp) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var urls []string
+	for i, v := range b.ops {
+		if v == op {
+			urls = append(urls, b.urls[i])
+		}
+	}
+	return urls
+}
+
+// runGenerate runs Session.GenerateWithConfig for the page of browser, using a session with a fake
+// SolarSystems API created with the given options (see newTestSession). The config is DefaultGenerateConfig,
+// modified by configure if it is non-nil.
+func runGenerate(
+	t *testing.T,
+	browser *testBrowser,
+	configure func(cfg *GenerateConfig),
+	options ...SessionOption,
+) (*GenerateResult, *testAPI, error) {
+	t.Helper()
+
+	session, api := newTestSession(t, options...)
+	cfg := DefaultGenerateConfig()
+	if configure != nil {
+		configure(&cfg)
+	}
+	result, err := session.GenerateWithConfig(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		cfg,
+	)
+	return result, api, err
+}
+
+func TestGenerateRequestValidate(t *testing.T) {
+	if err := testGenerateRequest().Validate(); err != nil {
+		t.Fatal("err != nil on valid request:", err)
+	}
+	if err := (&GenerateRequest{UserAgent: testUserAgent, Version: Version2, PageURL: testPageURL, BmSz: "bm_sz"}).Validate(); err != nil {
+		t.Fatal("err != nil on valid version 2 request:", err)
+	}
+
+	for _, modify := range []func(req *GenerateRequest){
+		func(req *GenerateRequest) { req.UserAgent = "" },
+		func(req *GenerateRequest) { req.Version = "" },
+		func(req *GenerateRequest) { req.Version = "3" },
+		func(req *GenerateRequest) { req.PageURL = "" },
+		func(req *GenerateRequest) { req.PageURL = "/product" },
+		func(req *GenerateRequest) { req.Version = Version2 },
+	} {
+		req := testGenerateRequest()
+		modify(req)
+		if err := req.Validate(); !errors.Is(err, ErrInvalidGenerateRequest) {
+			t.Fatalf("expected ErrInvalidGenerateRequest for %+v, got: %v", *req, err)
+		}
+	}
+
+	session, api := newTestSession(t)
+	if _, err := session.GenerateSensorData(context.Background(), &GenerateRequest{UserAgent: testUserAgent}); !errors.Is(err, ErrInvalidGenerateRequest) {
+		t.Fatal("expected ErrInvalidGenerateRequest, got:", err)
+	}
+	if _, err := session.GenerateSensorData(context.Background(), nil); !errors.Is(err, ErrInvalidGenerateRequest) {
+		t.Fatal("expected ErrInvalidGenerateRequest for nil request, got:", err)
+	}
+	if v := api.sensorCalls.Load(); v != 0 {
+		t.Fatal("expected no sensor API calls, got:", v)
+	}
+}
+
+func TestGenerateWithResult(t *testing.T) {
+	session, api := newTestSession(t)
+	browser := &testBrowser{abckCookies: []string{testInvalidAbck, testValidAbck}}
+
+	result, err := session.GenerateWithResult(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		3,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Cookies) != 1 || result.Cookies["_abck"] != testValidAbck {
+		t.Fatal("unexpected cookies:", result.Cookies)
+	}
+	result.Cookies = nil
+
+	expected := GenerateResult{
+		SensorPostCount:        2,
+		SensorPayloadBytes:     []int{len("payload"), len("payload")},
+		SensorMaxTries:         3,
+		PixelChallengePresent:  true,
+		PixelChallengeCount:    1,
+		PixelSolved:            true,
+		PixelSolvedCount:       1,
+		PixelPostStatus:        http.StatusOK,
+		PixelPayloadBytes:      len("payload"),
+		StoppedEarly:           true,
+		FinalCookieLikelyValid: true,
+		DetectedVersion:        Version175,
+		SensorPostURL:          "https://www.example.com/aBc-dEf/gHi",
+	}
+	if !reflect.DeepEqual(*result, expected) {
+		t.Fatalf("unexpected result: %+v", *result)
+	}
+	if v := api.sensorCalls.Load(); v != 2 {
+		t.Fatal("expected 2 sensor API calls, got:", v)
+	}
+	if v := api.pixelCalls.Load(); v != 1 {
+		t.Fatal("expected 1 pixel API call, got:", v)
+	}
+}
+
+func TestGenerateContextCancelled(t *testing.T) {
+	session, api := newTestSession(t)
+	browser := &testBrowser{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel the context once the page is fetched.
+	doHttpReq := func(ctx context.Context, op HttpReqOp, requestUrl, requestMethod string, requestBody io.Reader) (int, []byte, error) {
+		defer cancel()
+		return browser.doHttpReq(ctx, op, requestUrl, requestMethod, requestBody)
+	}
+
+	err := session.Generate(ctx, testUserAgent, testPageURL, doHttpReq, browser.getCookie, 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got:", err)
+	}
+	if v := api.sensorCalls.Load() + api.pixelCalls.Load(); v != 0 {
+		t.Fatal("expected no API calls, got:", v)
+	}
+}
+
+func TestGenerateUnsupportedScheme(t *testing.T) {
+	session, api := newTestSession(t)
+	browser := &testBrowser{}
+
+	for _, pageUrl := range []string{"ftp://www.example.com/product", "ws://www.example.com/product"} {
+		err := session.Generate(context.Background(), testUserAgent, pageUrl, browser.doHttpReq, browser.getCookie, 1)
+		if !errors.Is(err, ErrUnsupportedScheme) {
+			t.Fatal("expected ErrUnsupportedScheme, got:", err)
+		}
+	}
+	if len(browser.ops) != 0 || api.sensorCalls.Load() != 0 {
+		t.Fatal("expected no requests, got:", browser.ops)
+	}
+}
+
+func TestGenerateWithConfig(t *testing.T) {
+	endpointScript, err := os.ReadFile("tests/sdk_endpoint.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxiedPixelPage, err := os.ReadFile("tests/proxied_pixel.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const autoExtendAbck = "0C8A2251CC04F60F59160D6AD92DA8A0~3~YAAQlivJF6o1GjGGAQAA~-1~-1~-1"
+
+	for _, test := range []struct {
+		name      string
+		browser   *testBrowser
+		options   []SessionOption
+		configure func(cfg *GenerateConfig)
+
+		// wantErrs are the errors the returned error must match. If empty, it must be nil.
+		wantErrs []error
+
+		// check checks the outcome of generation, if non-nil.
+		check func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser)
+	}{
+		{
+			name:    "PixelPostURLFunc",
+			browser: &testBrowser{},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorMaxTries = 1
+				cfg.PixelMaxTries = 3
+				cfg.PixelCookie = "ak_pixel"
+				cfg.PixelPostURLFunc = func(scriptUrl string) string {
+					return scriptUrl + "/solve"
+				}
+			},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.SensorPostCount != 1 {
+					t.Fatal("expected 1 sensor data POST, got:", result.SensorPostCount)
+				}
+				const postUrl = "https://www.example.com/akam/13/1a2b3c/solve"
+				if v := browser.requests(OpPostPixelPayload); !reflect.DeepEqual(v, []string{postUrl, postUrl, postUrl}) {
+					t.Fatal("unexpected pixel challenge POSTs:", v)
+				}
+				if v := api.pixelCalls.Load(); v != 1 {
+					t.Fatal("expected 1 pixel API call, got:", v)
+				}
+			},
+		},
+		{
+			name:    "SensorPostURLFunc",
+			browser: &testBrowser{},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorPostURLFunc = func(scriptUrl string, pageUrl *url.URL) string {
+					if scriptUrl != "https://www.example.com/aBc-dEf/gHi" || pageUrl.String() != testPageURL {
+						return "https://www.example.com/unexpected-arguments"
+					}
+					return scriptUrl + "/sensor"
+				}
+			},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				const postUrl = "https://www.example.com/aBc-dEf/gHi/sensor"
+				if v := browser.requests(OpGetSdkScript); !reflect.DeepEqual(v, []string{"https://www.example.com/aBc-dEf/gHi"}) {
+					t.Fatal("unexpected script requests:", v)
+				}
+				if v := browser.requests(OpPostSensorData); !reflect.DeepEqual(v, []string{postUrl, postUrl}) {
+					t.Fatal("unexpected sensor data POSTs:", v)
+				}
+			},
+		},
+		{
+			name:      "SensorEndpointNotDetected",
+			browser:   &testBrowser{script: string(endpointScript)},
+			configure: func(cfg *GenerateConfig) { cfg.SensorMaxTries = 1 },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				const postUrl = "https://www.example.com/aBc-dEf/gHi"
+				if v := browser.requests(OpPostSensorData); result.SensorPostURL != postUrl || !reflect.DeepEqual(v, []string{postUrl}) {
+					t.Fatal("unexpected sensor data POSTs:", v)
+				}
+			},
+		},
+		{
+			name:    "DetectSensorEndpoint",
+			browser: &testBrowser{script: string(endpointScript)},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorMaxTries = 1
+				cfg.DetectSensorEndpoint = true
+			},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				const postUrl = "https://www.example.com/Ht4xQ/pB7uK/XuwD2/telemetry"
+				if v := browser.requests(OpPostSensorData); result.SensorPostURL != postUrl || !reflect.DeepEqual(v, []string{postUrl}) {
+					t.Fatal("unexpected sensor data POSTs:", v)
+				}
+			},
+		},
+		{
+			name:    "SensorBody",
+			browser: &testBrowser{},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorMaxTries = 1
+				cfg.SensorContentType = "text/plain"
+				cfg.SensorBodyFunc = func(payload string) []byte {
+					return []byte("raw:" + payload)
+				}
+			},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if !reflect.DeepEqual(browser.sensorBodies, []string{"raw:payload"}) ||
+					!reflect.DeepEqual(browser.sensorContentTypes, []string{"text/plain"}) {
+					t.Fatalf("unexpected sensor data requests: %v, %v", browser.sensorContentTypes, browser.sensorBodies)
+				}
+			},
+		},
+		{
+			name:    "ForceVersion",
+			browser: &testBrowser{cookies: map[string]string{"bm_sz": "bm_sz-0"}},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorMaxTries = 1
+				cfg.ForceVersion = Version2
+			},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.DetectedVersion != Version2 {
+					t.Fatal("unexpected version:", result.DetectedVersion)
+				}
+				if v := browser.requests(OpGetSdkScript); len(v) != 0 {
+					t.Fatal("unexpected web SDK script GET requests:", v)
+				}
+				if len(api.sensorRequests) != 1 || api.sensorRequests[0].Version != Version2 {
+					t.Fatal("unexpected sensor requests:", api.sensorRequests)
+				}
+			},
+		},
+		{
+			name:      "ForceUnknownVersion",
+			browser:   &testBrowser{},
+			configure: func(cfg *GenerateConfig) { cfg.ForceVersion = "3" },
+			wantErrs:  []error{ErrUnknownVersion},
+		},
+		{
+			name:      "EmptyScript",
+			browser:   &testBrowser{script: " \n"},
+			configure: func(cfg *GenerateConfig) { cfg.SensorMaxTries = 1 },
+			wantErrs:  []error{ErrEmptyScript},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if v := api.sensorCalls.Load(); v != 0 {
+					t.Fatal("expected no sensor API calls, got:", v)
+				}
+			},
+		},
+		{
+			name: "InlineScript",
+			browser: &testBrowser{page: `<html>
+<head>
+<script type="text/javascript">var _acxj=[];</script>
+</head>
+<body>Hello, world!</body>
+</html>`},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorMaxTries = 1
+				cfg.InlineSensorPostURL = "/aBc-dEf/gHi"
+			},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.DetectedVersion != Version175 || result.SensorPostCount != 1 {
+					t.Fatalf("unexpected result: %+v", *result)
+				}
+				if v := browser.requests(OpGetSdkScript); len(v) != 0 {
+					t.Fatal("unexpected web SDK script GET requests:", v)
+				}
+				if v := browser.requests(OpPostSensorData); !reflect.DeepEqual(v, []string{"https://www.example.com/aBc-dEf/gHi"}) {
+					t.Fatal("unexpected sensor data POSTs:", v)
+				}
+				if v := api.sensorCalls.Load(); v != 1 {
+					t.Fatal("expected 1 sensor API call, got:", v)
+				}
+			},
+		},
+		{
+			name:    "InlineScriptStrict",
+			browser: &testBrowser{page: `<html><script>var d={"sensor_data":""};</script></html>`},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorMaxTries = 1
+				cfg.InlineSensorPostURL = "/aBc-dEf/gHi"
+				cfg.Strict = true
+			},
+			wantErrs: []error{ErrUnrecognizedScript},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if v := api.sensorCalls.Load(); v != 0 {
+					t.Fatal("expected no sensor API calls, got:", v)
+				}
+			},
+		},
+		{
+			name: "InlineScriptVersionDetector",
+			browser: &testBrowser{
+				page:    `<html><script>(function(){})();</script><script>/* akamai */</script></html>`,
+				cookies: map[string]string{"bm_sz": "bm_sz-0"},
+			},
+			options: []SessionOption{WithVersionDetector(func(src []byte) (Version, bool) {
+				return Version2, bytes.HasPrefix(src, []byte("/* akamai */"))
+			})},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorMaxTries = 1
+				cfg.InlineSensorPostURL = "/aBc-dEf/gHi"
+			},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.DetectedVersion != Version2 || result.SensorPostCount != 1 {
+					t.Fatalf("unexpected result: %+v", *result)
+				}
+				if v := api.sensorCalls.Load(); v != 1 {
+					t.Fatal("expected 1 sensor API call, got:", v)
+				}
+			},
+		},
+		{
+			name: "MultiplePixelChallenges",
+			browser: &testBrowser{page: testPageBody + `
+<script type="text/javascript" src="https://www.example.com/akam/13/4d5e6f" defer></script>`},
+			configure: func(cfg *GenerateConfig) { cfg.SensorMaxTries = 1 },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.PixelChallengeCount != 2 || result.PixelSolvedCount != 2 {
+					t.Fatalf("unexpected result: %+v", *result)
+				}
+				postUrls := make(map[string]bool)
+				for _, v := range browser.requests(OpPostPixelPayload) {
+					postUrls[v] = true
+				}
+				if !postUrls["https://www.example.com/akam/13/pixel_1a2b3c"] ||
+					!postUrls["https://www.example.com/akam/13/pixel_4d5e6f"] || len(postUrls) != 2 {
+					t.Fatal("unexpected pixel challenge post URLs:", postUrls)
+				}
+				if v := api.pixelCalls.Load(); v != 2 {
+					t.Fatal("expected 2 pixel API calls, got:", v)
+				}
+			},
+		},
+		{
+			name:    "PixelURLPatterns",
+			browser: &testBrowser{page: string(proxiedPixelPage), abckCookies: []string{testValidAbck}},
+			options: []SessionOption{
+				WithPixelURLPatterns([]*regexp.Regexp{regexp.MustCompile(`src="(/static-assets/px/\w+)"`)}),
+			},
+			configure: func(cfg *GenerateConfig) { cfg.SensorMaxTries = 1 },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if !result.PixelSolved || api.pixelCalls.Load() != 1 {
+					t.Fatalf("expected the proxied pixel challenge to be solved: %+v", *result)
+				}
+				const postUrl = "https://www.example.com/static-assets/px/pixel_9a8b7c"
+				if v := browser.requests(OpPostPixelPayload); !reflect.DeepEqual(v, []string{postUrl}) {
+					t.Fatal("unexpected pixel challenge payload URLs:", v)
+				}
+			},
+		},
+		{
+			name:      "PixelAlreadySolved",
+			browser:   &testBrowser{pixelStatus: http.StatusNotFound},
+			configure: func(cfg *GenerateConfig) { cfg.SensorMaxTries = 1 },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if !result.PixelChallengePresent || !result.PixelAlreadySolved || result.PixelSolved {
+					t.Fatalf("unexpected result: %+v", *result)
+				}
+				if v := api.pixelCalls.Load(); v != 0 {
+					t.Fatal("expected no pixel API calls, got:", v)
+				}
+			},
+		},
+		{
+			name:      "PixelPostRejected",
+			browser:   &testBrowser{pixelPostStatus: http.StatusForbidden},
+			configure: func(cfg *GenerateConfig) { cfg.SensorMaxTries = 1 },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.PixelPostStatus != http.StatusForbidden {
+					t.Fatal("unexpected pixel challenge POST status:", result.PixelPostStatus)
+				}
+			},
+		},
+		{
+			name:      "StrictPixelPostRejected",
+			browser:   &testBrowser{pixelPostStatus: http.StatusForbidden},
+			configure: func(cfg *GenerateConfig) { cfg.Strict = true },
+			wantErrs:  []error{ErrPixelPostRejected, BadStatusCodeError{StatusCode: http.StatusForbidden}},
+		},
+		{
+			name:      "MaxBodyBytesExceeded",
+			browser:   &testBrowser{},
+			configure: func(cfg *GenerateConfig) { cfg.MaxBodyBytes = int64(len(testPageBody)) - 1 },
+			wantErrs:  []error{ErrBodyTooLarge, HttpOpError{Op: OpGetPage}},
+		},
+		{
+			name:      "MaxBodyBytes",
+			browser:   &testBrowser{},
+			configure: func(cfg *GenerateConfig) { cfg.MaxBodyBytes = int64(len(testPageBody)) },
+		},
+		{
+			name:    "NoScript",
+			browser: &testBrowser{page: "<html><body>Hello, world!</body></html>"},
+		},
+		{
+			name:    "RequireScript",
+			browser: &testBrowser{page: "<html><body>Hello, world!</body></html>"},
+			configure: func(cfg *GenerateConfig) {
+				cfg.RequireScript = true
+				cfg.RequirePixelChallenge = true
+			},
+			wantErrs: []error{ErrScriptNotFound, ErrPixelChallengeNotFound},
+		},
+		{
+			name:      "StrictNotHTML",
+			browser:   &testBrowser{page: `{"message":"Hello, world!"}`},
+			configure: func(cfg *GenerateConfig) { cfg.Strict = true },
+			wantErrs:  []error{ErrNotHTML},
+		},
+		{
+			name:      "StrictFinalCookieValid",
+			browser:   &testBrowser{abckCookies: []string{testInvalidAbck, testValidAbck}},
+			configure: func(cfg *GenerateConfig) { cfg.Strict = true },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if !result.FinalCookieLikelyValid {
+					t.Fatal("expected final cookie to be likely valid")
+				}
+			},
+		},
+		{
+			name:      "StrictCookieStillInvalid",
+			browser:   &testBrowser{abckCookies: []string{testInvalidAbck, testInvalidAbck}},
+			configure: func(cfg *GenerateConfig) { cfg.Strict = true },
+			wantErrs:  []error{ErrCookieStillInvalid},
+		},
+		{
+			name:    "FinalCookieInvalid",
+			browser: &testBrowser{abckCookies: []string{testInvalidAbck, testInvalidAbck}},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.FinalCookieLikelyValid {
+					t.Fatal("expected final cookie not to be likely valid")
+				}
+			},
+		},
+		{
+			// The stop signal threshold of 3 requires four POST requests.
+			name:      "AutoExtendTries",
+			browser:   &testBrowser{cookies: map[string]string{"_abck": autoExtendAbck}},
+			configure: func(cfg *GenerateConfig) { cfg.AutoExtendTries = true },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.SensorPostCount != 4 || result.SensorMaxTries != 4 || !result.StoppedEarly {
+					t.Fatalf("unexpected result: %+v", *result)
+				}
+			},
+		},
+		{
+			name:      "AutoExtendTriesCapped",
+			browser:   &testBrowser{cookies: map[string]string{"_abck": strings.Replace(autoExtendAbck, "~3~", "~100~", 1)}},
+			configure: func(cfg *GenerateConfig) { cfg.AutoExtendTries = true },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if result.SensorPostCount != maxAutoExtendedSensorTries || result.StoppedEarly {
+					t.Fatalf("unexpected result: %+v", *result)
+				}
+			},
+		},
+		{
+			name:      "AkBmsc",
+			browser:   &testBrowser{script: `var _cf=[];`, cookies: map[string]string{"ak_bmsc": "ak_bmsc-0"}},
+			configure: func(cfg *GenerateConfig) { cfg.SensorMaxTries = 1 },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if len(api.sensorRequests) != 1 {
+					t.Fatal("expected 1 sensor request, got:", len(api.sensorRequests))
+				}
+				if req := api.sensorRequests[0]; req.Version != Version17 || req.AkBmsc != "ak_bmsc-0" {
+					t.Fatalf("unexpected sensor request: %+v", req)
+				}
+			},
+		},
+		{
+			name: "RefreshesCookies",
+			browser: &testBrowser{
+				script:      `(function(){})();`,
+				cookies:     map[string]string{"_abck": "abck-0", "bm_sz": "bm_sz-0"},
+				abckCookies: []string{"abck-1", "abck-2"},
+				bmSzCookies: []string{"bm_sz-1", "bm_sz-2"},
+			},
+			configure: func(cfg *GenerateConfig) { cfg.SensorMaxTries = 3 },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if len(api.sensorRequests) != 3 {
+					t.Fatal("expected 3 sensor requests, got:", len(api.sensorRequests))
+				}
+				for i, req := range api.sensorRequests {
+					if req.Version != Version2 {
+						t.Fatal("unexpected version:", req.Version)
+					}
+					if abck := fmt.Sprintf("abck-%d", i); req.Abck != abck {
+						t.Fatalf("expected _abck %s, got: %s", abck, req.Abck)
+					}
+					if bmSz := fmt.Sprintf("bm_sz-%d", i); req.BmSz != bmSz {
+						t.Fatalf("expected bm_sz %s, got: %s", bmSz, req.BmSz)
+					}
+				}
+			},
+		},
+		{
+			name:    "FingerprintHints",
+			browser: &testBrowser{script: `(function(){})();`, cookies: map[string]string{"bm_sz": "bm_sz-0"}},
+			configure: func(cfg *GenerateConfig) {
+				cfg.SensorMaxTries = 1
+				cfg.AcceptLanguage = "en-US,en;q=0.9"
+				cfg.Timezone = "America/New_York"
+				cfg.ScreenResolution = "1920x1080"
+			},
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if len(api.sensorRequests) != 1 {
+					t.Fatal("expected 1 sensor request, got:", len(api.sensorRequests))
+				}
+				if req := api.sensorRequests[0]; req.AcceptLanguage != "en-US,en;q=0.9" ||
+					req.Timezone != "America/New_York" || req.ScreenResolution != "1920x1080" {
+					t.Fatalf("unexpected fingerprint hints: %+v", req)
+				}
+			},
+		},
+		{
+			name:      "DryRun",
+			browser:   &testBrowser{},
+			configure: func(cfg *GenerateConfig) { cfg.DryRun = true },
+			check: func(t *testing.T, result *GenerateResult, api *testAPI, browser *testBrowser) {
+				if !result.DryRun || !result.PixelChallengePresent || result.DetectedVersion != Version175 {
+					t.Fatalf("unexpected result: %+v", *result)
+				}
+				if v := api.sensorCalls.Load() + api.pixelCalls.Load(); v != 0 {
+					t.Fatal("expected no API calls, got:", v)
+				}
+				if v := append(browser.requests(OpPostSensorData), browser.requests(OpPostPixelPayload)...); len(v) != 0 {
+					t.Fatal("unexpected POST requests:", v)
+				}
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			result, api, err := runGenerate(t, test.browser, test.configure, test.options...)
+			if len(test.wantErrs) == 0 && err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.wantErrs {
+				if !errors.Is(err, want) {
+					t.Fatalf("expected error matching %v, got: %v", want, err)
+				}
+			}
+			if test.check != nil {
+				test.check(t, result, api, test.browser)
+			}
+		})
+	}
+}
+
+func TestGenerateRequestFingerprintHintsOmitted(t *testing.T) {
+	encoded, err := json.Marshal(GenerateRequest{UserAgent: testUserAgent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(encoded, []byte("acceptLanguage")) || bytes.Contains(encoded, []byte("timezone")) ||
+		bytes.Contains(encoded, []byte("screenResolution")) {
+		t.Fatal("expected empty fingerprint hints to be omitted:", string(encoded))
+	}
+}
+
+func TestGenerateFromPage(t *testing.T) {
+	session, api := newTestSession(t)
+	browser := &testBrowser{}
+
+	if err := session.GenerateFromPage(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		[]byte(testPageBody),
+		browser.doHttpReq,
+		browser.getCookie,
+		1,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, op := range browser.ops {
+		if op == OpGetPage {
+			t.Fatal("unexpected page GET request")
+		}
+	}
+	if len(api.sensorRequests) != 1 {
+		t.Fatal("expected 1 sensor request, got:", len(api.sensorRequests))
+	}
+
+	if err := session.GenerateFromPage(
+		context.Background(),
+		testUserAgent,
+		"/relative",
+		[]byte(testPageBody),
+		browser.doHttpReq,
+		browser.getCookie,
+		1,
+	); !errors.Is(err, ErrInvalidPageURL) {
+		t.Fatal("expected ErrInvalidPageURL, got:", err)
+	}
+}
+
+func TestGenerateFailFast(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case sensorEndpoint.path:
+			w.WriteHeader(http.StatusBadRequest)
+		case pixelEndpoint.path:
+			// Block until the request is aborted by the failing sensor worker. The body must be read
+			// for the server to notice the client going away.
+			_, _ = io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"payload":"payload"}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL))
+	browser := &testBrowser{}
+
+	cfg := DefaultGenerateConfig()
+	cfg.FailFast = true
+	_, err := session.GenerateWithConfig(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		cfg,
+	)
+
+	var failFastErr FailFastError
+	if !errors.As(err, &failFastErr) {
+		t.Fatal("expected FailFastError, got:", err)
+	}
+	if failFastErr.Worker != "sensor" {
+		t.Fatal("expected sensor worker to fail first, got:", failFastErr.Worker)
+	}
+	var apiErr ApiOperationError
+	if !errors.As(failFastErr, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatal("unexpected worker error:", failFastErr.Err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("expected the pixel worker to be cancelled, got:", err)
+	}
+	for _, op := range browser.ops {
+		if op == OpPostPixelPayload {
+			t.Fatal("unexpected pixel challenge payload POST")
+		}
+	}
+}
+
+func TestGenerateFailFastCancelsHttpReq(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == pixelEndpoint.path {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"payload":"payload"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL))
+	browser := &testBrowser{}
+	pixelErr := make(chan error, 1)
+	doHttpReq := func(
+		ctx context.Context,
+		op HttpReqOp,
+		requestUrl,
+		requestMethod string,
+		requestBody io.Reader,
+	) (int, []byte, error) {
+		if op == OpGetPixelChallengeScript {
+			// Block until the request is aborted by the failing sensor worker.
+			select {
+			case <-ctx.Done():
+				pixelErr <- ctx.Err()
+				return 0, nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				pixelErr <- nil
+			}
+		}
+		return browser.doHttpReq(ctx, op, requestUrl, requestMethod, requestBody)
+	}
+
+	cfg := DefaultGenerateConfig()
+	cfg.FailFast = true
+	_, err := session.GenerateWithConfig(context.Background(), testUserAgent, testPageURL, doHttpReq, browser.getCookie, cfg)
+
+	var failFastErr FailFastError
+	if !errors.As(err, &failFastErr) || failFastErr.Worker != "sensor" {
+		t.Fatal("expected FailFastError of the sensor worker, got:", err)
+	}
+	if v := <-pixelErr; v != context.Canceled {
+		t.Fatal("expected the pixel challenge script request to be cancelled, got:", v)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("expected the pixel worker error to be context.Canceled, got:", err)
+	}
+}
+
+// testTimerClock is a ClockTimer recording the durations waited for. Waits end immediately if fire is set,
+// otherwise they never end.
+type testTimerClock struct {
+	manualClock
+	fire bool
+
+	afterMu sync.Mutex
+	delays  []time.Duration
+}
+
+func (c *testTimerClock) After(d time.Duration) <-chan time.Time {
+	c.afterMu.Lock()
+	defer c.afterMu.Unlock()
+	c.delays = append(c.delays, d)
+
+	ch := make(chan time.Time, 1)
+	if c.fire {
+		ch <- c.Now().Add(d)
+	}
+	return ch
+}
+
+func TestGenerateInterPostDelay(t *testing.T) {
+	clock := &testTimerClock{fire: true}
+	browser := &testBrowser{abckCookies: []string{testInvalidAbck, testInvalidAbck}}
+	configure := func(cfg *GenerateConfig) {
+		cfg.InterPostDelay = 50 * time.Millisecond
+		cfg.InterPostJitter = 10 * time.Millisecond
+	}
+	if _, _, err := runGenerate(t, browser, configure, WithClock(clock)); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.delays) != 1 {
+		t.Fatal("expected 1 delay between 2 sensor data POST requests, got:", clock.delays)
+	}
+	if v := clock.delays[0]; v < 50*time.Millisecond || v > 60*time.Millisecond {
+		t.Fatal("unexpected delay between sensor data POST requests:", v)
+	}
+
+	// The delay is cancellable
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session, _ := newTestSession(t, WithClock(&testTimerClock{}))
+	cfg := DefaultGenerateConfig()
+	configure(&cfg)
+	browser = &testBrowser{abckCookies: []string{testInvalidAbck, testInvalidAbck}}
+	cancelling := func(
+		ctx context.Context,
+		op HttpReqOp,
+		requestUrl,
+		requestMethod string,
+		requestBody io.Reader,
+	) (int, []byte, error) {
+		statusCode, body, err := browser.doHttpReq(ctx, op, requestUrl, requestMethod, requestBody)
+		if op == OpPostSensorData {
+			cancel()
+		}
+		return statusCode, body, err
+	}
+	_, err := session.GenerateWithConfig(ctx, testUserAgent, testPageURL, cancelling, browser.getCookie, cfg)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got:", err)
+	}
+	if v := browser.requests(OpPostSensorData); len(v) != 1 {
+		t.Fatal("expected 1 sensor data POST request, got:", v)
+	}
+}
+
+func TestGenerateSequential(t *testing.T) {
+	session, api := newTestSession(t)
+
+	cfg := DefaultGenerateConfig()
+	cfg.Sequential = true
+	expected := []HttpReqOp{
+		OpGetPage,
+		OpGetPixelChallengeScript,
+		OpPostPixelPayload,
+		OpGetSdkScript,
+		OpPostSensorData,
+		OpPostSensorData,
+	}
+	for i := 0; i < 10; i++ {
+		browser := &testBrowser{abckCookies: []string{testInvalidAbck, testValidAbck}}
+		if _, err := session.GenerateWithConfig(
+			context.Background(),
+			testUserAgent,
+			testPageURL,
+			browser.doHttpReq,
+			browser.getCookie,
+			cfg,
+		); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(browser.ops, expected) {
+			t.Fatal("unexpected operations:", browser.ops)
+		}
+	}
+
+	// With fail fast, sensor data is not generated once the pixel challenge failed.
+	api.sensorCalls.Store(0)
+	cfg.FailFast = true
+	browser := &testBrowser{pixelStatus: http.StatusInternalServerError}
+	_, err := session.GenerateWithConfig(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		cfg,
+	)
+	var failFastErr FailFastError
+	if !errors.As(err, &failFastErr) || failFastErr.Worker != "pixel" {
+		t.Fatal("expected FailFastError, got:", err)
+	}
+	if v := api.sensorCalls.Load(); v != 0 {
+		t.Fatal("expected no sensor API calls, got:", v)
+	}
+}
+
+func TestRefreshSensor(t *testing.T) {
+	session, api := newTestSession(t)
+	browser := &testBrowser{abckCookies: []string{testInvalidAbck, testValidAbck}}
+
+	result, err := session.RefreshSensor(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		"/aBc-dEf/gHi",
+		Version175,
+		browser.doHttpReq,
+		browser.getCookie,
+		3,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SensorPostCount != 2 || !result.StoppedEarly || result.Cookies["_abck"] != testValidAbck {
+		t.Fatalf("unexpected result: %+v", *result)
+	}
+	if result.SensorPostURL != "https://www.example.com/aBc-dEf/gHi" || result.DetectedVersion != Version175 {
+		t.Fatalf("unexpected result: %+v", *result)
+	}
+	if v := api.sensorCalls.Load(); v != 2 {
+		t.Fatal("expected 2 sensor API calls, got:", v)
+	}
+	for i, op := range browser.ops {
+		if op != OpPostSensorData {
+			t.Fatal("unexpected request:", op)
+		}
+		if v := browser.urls[i]; v != result.SensorPostURL {
+			t.Fatal("unexpected sensor data URL:", v)
+		}
+	}
+
+	if _, err = session.RefreshSensor(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		"/aBc-dEf/gHi",
+		"9.9",
+		browser.doHttpReq,
+		browser.getCookie,
+		1,
+	); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatal("expected ErrUnknownVersion, got:", err)
+	}
+}
+
+func TestGeneratePartialResult(t *testing.T) {
+	session, _ := newTestSession(t)
+	browser := &testBrowser{pixelStatus: http.StatusInternalServerError, abckCookies: []string{testValidAbck}}
+
+	result, err := session.GenerateWithResult(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		2,
+	)
+	if !IsBadStatus(err, http.StatusInternalServerError) {
+		t.Fatal("expected pixel challenge error, got:", err)
+	}
+	if result == nil {
+		t.Fatal("result == nil on partial success")
+	}
+	if result.PixelSolved || result.SensorPostCount != 1 || !result.FinalCookieLikelyValid {
+		t.Fatalf("unexpected result: %+v", *result)
+	}
+	if v := result.Cookies["_abck"]; v != testValidAbck {
+		t.Fatal("unexpected _abck cookie:", v)
+	}
+
+	// Results are only partial once generation started.
+	browser.page = `{"message":"Hello, world!"}`
+	if result, _, err = runGenerate(t, browser, func(cfg *GenerateConfig) {
+		cfg.Strict = true
+	}); err == nil || result != nil {
+		t.Fatalf("unexpected result for failed generation: %v, %v", result, err)
+	}
+}
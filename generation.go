@@ -0,0 +1,605 @@
+package akamai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// generation is the state of a single call to Session.GenerateWithConfig.
+type generation struct {
+	session   Session
+	ctx       context.Context
+	cfg       GenerateConfig
+	userAgent string
+	pageUrl   string
+	u         *url.URL
+	pageBody  []byte
+	doHttpReq DoHttpReqFunc
+	getCookie GetCookieFunc
+
+	// result is written to by the workers. Each field is only written to by one worker.
+	result GenerateResult
+
+	// errs are the errors reported by the workers.
+	errs []error
+	mu   sync.Mutex
+
+	// eventMu serializes calls to GenerateConfig.OnEvent.
+	eventMu sync.Mutex
+}
+
+// run runs all workers concurrently, or one after another if GenerateConfig.Sequential is set, and waits
+// for them to complete. The returned error joins the errors reported by the workers.
+func (g *generation) run() error {
+	workers := []struct {
+		name string
+		run  func() error
+	}{
+		{"pixel", g.solvePixelChallenge},
+		{"sec_cpt", g.solveSecCptChallenge},
+		{"sensor", g.generateAbck},
+	}
+
+	// cancel cancels the context of the workers once one of them fails, if fail fast is enabled.
+	var cancel context.CancelFunc = func() {}
+	if g.cfg.FailFast {
+		g.ctx, cancel = context.WithCancel(g.ctx)
+	}
+	defer cancel()
+
+	if g.cfg.Sequential {
+		for _, worker := range workers {
+			if g.cfg.FailFast && len(g.errs) > 0 {
+				break
+			}
+			if err := worker.run(); err != nil {
+				g.addError(worker.name, err, cancel)
+			}
+		}
+		return errors.Join(g.errs...)
+	}
+
+	// wg is the WaitGroup for all worker goroutines.
+	var wg sync.WaitGroup
+	wg.Add(len(workers))
+	for _, worker := range workers {
+		go func(name string, run func() error) {
+			defer wg.Done()
+
+			if err := run(); err != nil {
+				g.addError(name, err, cancel)
+			}
+		}(worker.name, worker.run)
+	}
+
+	wg.Wait()
+	return errors.Join(g.errs...)
+}
+
+// collectCookies sets the cookies of the result. It must only be called once all workers are done.
+func (g *generation) collectCookies() {
+	names := AkamaiCookieNames
+	if g.cfg.PixelCookie != "" {
+		names = append(names[:len(names):len(names)], g.cfg.PixelCookie)
+	}
+
+	g.result.Cookies = make(map[string]string, len(names))
+	for _, name := range names {
+		if value := g.getCookie(g.u, name); value != "" {
+			g.result.Cookies[name] = value
+		}
+	}
+}
+
+// addError appends the error of the named worker to errs. If fail fast is enabled and it is the first error,
+// it is wrapped in a FailFastError and cancel is called. It is safe for usage by multiple goroutines.
+func (g *generation) addError(worker string, err error, cancel context.CancelFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.cfg.FailFast && len(g.errs) == 0 {
+		err = FailFastError{Worker: worker, Err: err}
+		cancel()
+	}
+	g.errs = append(g.errs, err)
+}
+
+// checkCancelled returns an error for the given operation if the context is done.
+func (g *generation) checkCancelled(op HttpReqOp) error {
+	select {
+	case <-g.ctx.Done():
+		return errors.Join(HttpOpError{Op: op}, g.ctx.Err())
+	default:
+		return nil
+	}
+}
+
+// solvePixelChallenge solves the pixel challenges, if present. Multiple challenges are solved concurrently,
+// unless GenerateConfig.Sequential is set.
+func (g *generation) solvePixelChallenge() error {
+	// Get the scripts' URLs and the URLs to post the payloads to
+	locations := GetAllPixelChallengeScriptURLsMatching(g.pageBody, g.session.pixelPatterns)
+	if len(locations) == 0 {
+		// Pixel challenge is not present on this page.
+		g.session.debugf("akamai-sdk-go: pixel challenge not present")
+		if g.cfg.RequirePixelChallenge {
+			return ErrPixelChallengeNotFound
+		}
+		return nil
+	}
+	g.result.PixelChallengePresent = true
+	g.result.PixelChallengeCount = len(locations)
+
+	// Get the HTML variable of each challenge
+	htmlVars := make([]int, len(locations))
+	for i, location := range locations {
+		htmlVar, err := GetPixelChallengeHtmlVarFor(g.pageBody, location.ScriptURL)
+		if err != nil {
+			return err
+		}
+		htmlVars[i] = htmlVar
+	}
+
+	outcomes := make([]pixelOutcome, len(locations))
+	errs := make([]error, len(locations))
+	solve := func(i int, location PixelChallengeLocation) {
+		ctx, endSpan := g.session.startSpan(g.ctx, SpanSolvePixelChallenge)
+		outcomes[i], errs[i] = g.solvePixelChallengeAt(ctx, location, htmlVars[i])
+		endSpan(errs[i])
+	}
+	if g.cfg.Sequential {
+		for i, location := range locations {
+			solve(i, location)
+		}
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(len(locations))
+		for i, location := range locations {
+			go func(i int, location PixelChallengeLocation) {
+				defer wg.Done()
+				solve(i, location)
+			}(i, location)
+		}
+		wg.Wait()
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.solved {
+			g.result.PixelSolved = true
+			g.result.PixelSolvedCount++
+		}
+		if outcome.alreadySolved {
+			g.result.PixelAlreadySolved = true
+		}
+		g.result.PixelPayloadBytes += outcome.payloadBytes
+		if outcome.postStatus != 0 && (g.result.PixelPostStatus == 0 || isSuccessStatus(g.result.PixelPostStatus)) {
+			g.result.PixelPostStatus = outcome.postStatus
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pixelOutcome is the outcome of solving a single pixel challenge.
+type pixelOutcome struct {
+	// solved reports if the payload was posted.
+	solved bool
+
+	// alreadySolved reports if the challenge was already solved.
+	alreadySolved bool
+
+	// postStatus is the status code of the last payload POST request, or zero if none was sent.
+	postStatus int
+
+	// payloadBytes is the length of the generated payload, or zero if none was generated.
+	payloadBytes int
+}
+
+// solvePixelChallengeAt solves the pixel challenge at the given location.
+func (g *generation) solvePixelChallengeAt(
+	ctx context.Context,
+	location PixelChallengeLocation,
+	htmlVar int,
+) (outcome pixelOutcome, err error) {
+	scriptUrl, postUrl := AbsolutizePixelURL(g.u, location.ScriptURL), AbsolutizePixelURL(g.u, location.PostURL)
+	g.session.debugf("akamai-sdk-go: pixel challenge present, script %s", scriptUrl)
+	if g.cfg.PixelPostURLFunc != nil {
+		postUrl = g.cfg.PixelPostURLFunc(scriptUrl)
+	}
+
+	// GET request to pixel script
+	statusCode, scriptBody, err := g.doHttpReq(ctx, OpGetPixelChallengeScript, scriptUrl, http.MethodGet, nil)
+	if err == nil && statusCode != http.StatusOK {
+		if IsPixelAlreadySolved(statusCode) {
+			// Pixel challenge script returns 404 when the challenge is already solved.
+			g.session.debugf("akamai-sdk-go: pixel challenge already solved")
+			return pixelOutcome{alreadySolved: true}, nil
+		}
+
+		err = BadStatusCodeError{StatusCode: statusCode}
+	}
+	if err != nil {
+		return outcome, err
+	}
+
+	// Get dynamic script variable
+	scriptVar, err := GetPixelChallengeScriptVar(scriptBody)
+	if err != nil {
+		return outcome, err
+	}
+
+	if g.cfg.DryRun {
+		g.session.debugf("akamai-sdk-go: dry run, skipping pixel challenge payload")
+		return pixelOutcome{}, nil
+	}
+
+	// Generate payload
+	if err = g.checkCancelled(OpPostPixelPayload); err != nil {
+		return outcome, err
+	}
+	if err = g.session.checkTargetHost(OpPostPixelPayload, postUrl); err != nil {
+		return outcome, err
+	}
+	response, err := g.session.GeneratePixelPayload(ctx, &PixelSolveRequest{
+		UserAgent: g.userAgent,
+		HtmlVar:   htmlVar,
+		ScriptVar: scriptVar,
+	})
+	if err != nil {
+		return outcome, err
+	}
+	outcome.payloadBytes = len(response.Payload)
+
+	// POST payload, and again while the pixel cookie isn't set
+	tries := g.cfg.PixelMaxTries
+	if tries < 1 {
+		tries = 1
+	}
+	for i := 0; i < tries; i++ {
+		if i > 0 && (g.cfg.PixelCookie == "" || g.getCookie(g.u, g.cfg.PixelCookie) != "") {
+			break
+		}
+		if err = g.checkCancelled(OpPostPixelPayload); err != nil {
+			return outcome, err
+		}
+
+		statusCode, _, err := g.doHttpReq(
+			ctx,
+			OpPostPixelPayload,
+			postUrl,
+			http.MethodPost,
+			bytes.NewBufferString(response.Payload),
+		)
+		if err != nil {
+			return outcome, err
+		}
+		outcome.postStatus = statusCode
+		g.emit(GenerateEvent{Type: EventPixelPosted, Attempt: i + 1})
+	}
+	outcome.solved = true
+	g.session.debugf("akamai-sdk-go: posted pixel challenge payload (HTTP %d)", outcome.postStatus)
+
+	if g.cfg.Strict && !isSuccessStatus(outcome.postStatus) {
+		return outcome, errors.Join(ErrPixelPostRejected, BadStatusCodeError{StatusCode: outcome.postStatus})
+	}
+	return outcome, nil
+}
+
+// solveSecCptChallenge solves the sec_cpt challenge, if it is present and GenerateConfig.SolveSecCpt is set.
+func (g *generation) solveSecCptChallenge() error {
+	// Get the challenge path
+	ok, challengePath := GetSecCptChallenge(g.pageBody)
+	if !ok {
+		// sec_cpt challenge is not present on this page.
+		return nil
+	}
+	g.result.SecCptChallengePresent = true
+	g.session.debugf("akamai-sdk-go: sec_cpt challenge present, path %s", challengePath)
+	if !g.cfg.SolveSecCpt {
+		g.session.debugf("akamai-sdk-go: solving sec_cpt challenge not enabled, skipping")
+		return nil
+	}
+
+	if g.cfg.DryRun {
+		g.session.debugf("akamai-sdk-go: dry run, skipping sec_cpt challenge payload")
+		return nil
+	}
+
+	// Generate payload
+	if err := g.checkCancelled(OpPostSecCpt); err != nil {
+		return err
+	}
+	verifyUrl, err := AbsolutizeURL(g.u, secCptVerifyPath)
+	if err != nil {
+		return err
+	}
+	if err = g.session.checkTargetHost(OpPostSecCpt, verifyUrl); err != nil {
+		return err
+	}
+	response, err := g.session.GenerateSecCptPayload(g.ctx, &SecCptSolveRequest{
+		UserAgent:     g.userAgent,
+		PageURL:       g.pageUrl,
+		ChallengePath: challengePath,
+		SecCpt:        g.getCookie(g.u, "sec_cpt"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// POST payload
+	if _, _, err = g.doHttpReq(
+		g.ctx,
+		OpPostSecCpt,
+		verifyUrl,
+		http.MethodPost,
+		bytes.NewBufferString(response.Payload),
+	); err != nil {
+		return err
+	}
+	g.result.SecCptSolved = true
+	g.session.debugf("akamai-sdk-go: posted sec_cpt challenge payload")
+	return nil
+}
+
+// sdkVersion returns GenerateConfig.ForceVersion if it is set, or detects the web SDK version from the
+// inline script if it is non-nil, or by fetching the script at scriptUrl otherwise. scriptBody is the body
+// of the fetched script, or nil if it was not fetched.
+func (g *generation) sdkVersion(scriptUrl string, inlineScript []byte) (version Version, scriptBody []byte, err error) {
+	if g.cfg.ForceVersion != "" {
+		g.session.debugf("akamai-sdk-go: using forced web SDK version %s", g.cfg.ForceVersion)
+		return g.cfg.ForceVersion, nil, nil
+	}
+	if inlineScript != nil {
+		var recognized bool
+		version, recognized = g.session.detectSdkVersion(inlineScript)
+		if !recognized && g.cfg.Strict {
+			return "", nil, ErrUnrecognizedScript
+		}
+		if !version.IsKnown() {
+			return "", nil, ErrUnknownVersion
+		}
+		g.session.debugf("akamai-sdk-go: detected web SDK version %s from inline script", version)
+		return version, nil, nil
+	}
+
+	// GET request to script
+	ctx, endSpan := g.session.startSpan(g.ctx, SpanGetSdkScript)
+	statusCode, scriptBody, err := g.doHttpReq(ctx, OpGetSdkScript, scriptUrl, http.MethodGet, nil)
+	if err == nil && statusCode != http.StatusOK {
+		err = BadStatusCodeError{StatusCode: statusCode}
+	}
+	endSpan(err)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(bytes.TrimSpace(scriptBody)) == 0 {
+		return "", nil, ErrEmptyScript
+	}
+	g.emit(GenerateEvent{Type: EventScriptFetched})
+
+	version, recognized := g.session.detectSdkVersion(scriptBody)
+	if !recognized && g.cfg.Strict {
+		return "", nil, ErrUnrecognizedScript
+	}
+	if !version.IsKnown() {
+		return "", nil, ErrUnknownVersion
+	}
+	g.session.debugf("akamai-sdk-go: detected web SDK version %s from script %s", version, scriptUrl)
+	return version, scriptBody, nil
+}
+
+// generateAndPostSensorData generates sensor data for the given request and posts it to postUrl. Invalid
+// payloads are generated again if GenerateConfig.SensorPayloadRetries is set.
+func (g *generation) generateAndPostSensorData(ctx context.Context, request *GenerateRequest, postUrl string) error {
+	response, err := g.session.GenerateSensorData(ctx, request)
+	if err != nil {
+		return err
+	}
+	for retries := 0; g.cfg.SensorPayloadRetries > 0; retries++ {
+		if err = ValidateSensorPayload(request.Version, response.Payload); err == nil {
+			break
+		}
+		if retries == g.cfg.SensorPayloadRetries {
+			return err
+		}
+		g.session.debugf("akamai-sdk-go: generating sensor data again: %v", err)
+		if response, err = g.session.GenerateSensorData(ctx, request); err != nil {
+			return err
+		}
+	}
+	g.result.SensorPayloadBytes = append(g.result.SensorPayloadBytes, len(response.Payload))
+	return g.postSensorData(ctx, postUrl, response.Payload)
+}
+
+// postSensorData posts the given sensor data payload to postUrl, using GenerateConfig.SensorBodyFunc and
+// GenerateConfig.SensorContentType if set.
+func (g *generation) postSensorData(ctx context.Context, postUrl, payload string) error {
+	if g.cfg.SensorContentType != "" {
+		ctx = context.WithValue(ctx, contentTypeKey{}, g.cfg.SensorContentType)
+	}
+
+	if g.cfg.SensorBodyFunc != nil {
+		body := bytes.NewReader(g.cfg.SensorBodyFunc(payload))
+		_, _, err := g.doHttpReq(ctx, OpPostSensorData, postUrl, http.MethodPost, body)
+		return err
+	}
+
+	body := getSensorDataBody(payload)
+	_, _, err := g.doHttpReq(ctx, OpPostSensorData, postUrl, http.MethodPost, body)
+	putSensorDataBody(body)
+	return err
+}
+
+// generateAbck generates and posts sensor data to obtain a valid _abck cookie.
+func (g *generation) generateAbck() error {
+	// Get script URL
+	ok, scriptUrl, _ := GetScriptURL(g.pageBody)
+	var inlineScript []byte
+	if !ok && g.cfg.InlineSensorPostURL != "" {
+		if ok, inlineScript = g.session.getInlineScript(g.pageBody); ok {
+			scriptUrl = g.cfg.InlineSensorPostURL
+			g.session.debugf("akamai-sdk-go: web SDK script inlined, posting sensor data to %s", scriptUrl)
+		}
+	}
+	if !ok {
+		// If there's no script on the page then we skip generating.
+		g.session.debugf("akamai-sdk-go: web SDK script not found")
+		if g.cfg.RequireScript {
+			return ErrScriptNotFound
+		}
+		return nil
+	}
+	scriptUrl, err := AbsolutizeURL(g.u, scriptUrl)
+	if err != nil {
+		return err
+	}
+
+	// Get SDK version
+	version, scriptBody, err := g.sdkVersion(scriptUrl, inlineScript)
+	if err != nil {
+		return err
+	}
+	g.result.DetectedVersion = version
+
+	postUrl := scriptUrl
+	if g.cfg.SensorPostURLFunc != nil {
+		postUrl = g.cfg.SensorPostURLFunc(scriptUrl, g.u)
+		g.session.debugf("akamai-sdk-go: posting sensor data to %s", postUrl)
+	} else if path, ok := GetSensorEndpoint(scriptBody); ok && g.cfg.DetectSensorEndpoint {
+		if endpointUrl, err := AbsolutizeURL(g.u, path); err == nil {
+			postUrl = endpointUrl
+			g.session.debugf("akamai-sdk-go: web SDK script embeds sensor data endpoint %s", postUrl)
+		}
+	}
+	g.result.SensorPostURL = postUrl
+
+	if err = g.refreshMalformedBmSz(version); err != nil {
+		return err
+	}
+
+	if g.cfg.DryRun {
+		g.session.debugf("akamai-sdk-go: dry run, skipping sensor data")
+		return nil
+	}
+	return g.postSensorDataUntilValid(version, postUrl)
+}
+
+// waitInterPostDelay waits for GenerateConfig.InterPostDelay plus a random jitter of up to
+// GenerateConfig.InterPostJitter, according to the session's Clock. It returns an error if the context is
+// done before.
+func (g *generation) waitInterPostDelay() error {
+	delay := g.cfg.InterPostDelay
+	if g.cfg.InterPostJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(g.cfg.InterPostJitter) + 1))
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	if err := g.session.sleep(g.ctx, delay); err != nil {
+		return errors.Join(HttpOpError{Op: OpPostSensorData}, err)
+	}
+	return nil
+}
+
+// refreshMalformedBmSz fetches the page again to refresh the bm_sz cookie if it is required by version and
+// missing or malformed, and refreshing is enabled with WithBmSzRefresh.
+func (g *generation) refreshMalformedBmSz(version Version) error {
+	if !RequiresBmSz(version) || !g.session.refreshBmSz || !IsBmSzMalformed(g.getCookie(g.u, "bm_sz")) {
+		return nil
+	}
+
+	statusCode, _, err := g.doHttpReq(g.ctx, OpGetPage, g.pageUrl, http.MethodGet, nil)
+	if err == nil && statusCode != http.StatusOK {
+		err = BadStatusCodeError{StatusCode: statusCode}
+	}
+	if err != nil {
+		return errors.Join(HttpOpError{Op: OpGetPage}, err)
+	}
+	return nil
+}
+
+// postSensorDataUntilValid generates and posts sensor data for the given web SDK version to postUrl, until
+// the stop signal reports the _abck cookie as valid or the tries are exhausted.
+func (g *generation) postSensorDataUntilValid(version Version, postUrl string) error {
+	if err := g.session.checkTargetHost(OpPostSensorData, postUrl); err != nil {
+		return err
+	}
+
+	// Generate and post sensor data
+	var err error
+	maxTries := g.cfg.SensorMaxTries
+	for i := 0; i < maxTries; i++ {
+		if i > 0 {
+			if err = g.waitInterPostDelay(); err != nil {
+				return err
+			}
+		}
+		if err = g.checkCancelled(OpPostSensorData); err != nil {
+			return err
+		}
+
+		// Cookies are read on every try, as posting sensor data can rotate both _abck and bm_sz.
+		request := GenerateRequest{
+			UserAgent:        g.userAgent,
+			Version:          version,
+			PageURL:          g.pageUrl,
+			Abck:             NormalizeAbck(g.getCookie(g.u, "_abck")),
+			AcceptLanguage:   g.cfg.AcceptLanguage,
+			Timezone:         g.cfg.Timezone,
+			ScreenResolution: g.cfg.ScreenResolution,
+		}
+		if version == Version17 {
+			request.AkBmsc = g.getCookie(g.u, "ak_bmsc")
+		}
+		if RequiresBmSz(version) {
+			request.BmSz = g.getCookie(g.u, "bm_sz")
+		}
+
+		ctx, endSpan := g.session.startSpan(g.ctx, SpanPostSensorData)
+		err = g.generateAndPostSensorData(ctx, &request, postUrl)
+		endSpan(err)
+		if err != nil {
+			return err
+		}
+		g.result.SensorPostCount++
+
+		abck := g.getCookie(g.u, "_abck")
+		valid := IsCookieValid(abck, i)
+		g.emit(GenerateEvent{Type: EventSensorPosted, Attempt: i + 1, Valid: valid})
+		g.session.debugf("akamai-sdk-go: posted sensor data (try %d/%d), stop signal: %t", i+1, maxTries, valid)
+		if valid {
+			g.result.StoppedEarly = true
+			break
+		}
+
+		// Extend the tries up to the stop signal threshold
+		if threshold, ok := ParseStopSignal(abck); ok && g.cfg.AutoExtendTries && threshold >= maxTries {
+			extended := threshold + 1
+			if extended > maxAutoExtendedSensorTries {
+				extended = maxAutoExtendedSensorTries
+			}
+			if extended > maxTries {
+				g.session.debugf(
+					"akamai-sdk-go: extending sensor data tries to %d for stop signal threshold %d",
+					extended,
+					threshold,
+				)
+				maxTries = extended
+			}
+		}
+	}
+	g.result.SensorMaxTries = maxTries
+
+	abck := g.getCookie(g.u, "_abck")
+	g.result.FinalCookieLikelyValid = IsCookieValid(abck, g.result.SensorPostCount-1)
+	if g.cfg.Strict && !g.result.FinalCookieLikelyValid && ParseAbckState(abck) <= AbckInvalid {
+		return ErrCookieStillInvalid
+	}
+	return nil
+}
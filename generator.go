@@ -0,0 +1,38 @@
+package akamai
+
+import "context"
+
+// Generator is the set of generation methods implemented by Session. Callers can depend on Generator
+// instead of Session to substitute a fake implementation in tests, like akamaitest.MockSession.
+type Generator interface {
+	// Generate is Session.Generate.
+	Generate(
+		ctx context.Context,
+		userAgent,
+		pageUrl string,
+		doHttpReq DoHttpReqFunc,
+		getCookie GetCookieFunc,
+		maxTries int,
+	) error
+
+	// GenerateWithResult is Session.GenerateWithResult.
+	GenerateWithResult(
+		ctx context.Context,
+		userAgent,
+		pageUrl string,
+		doHttpReq DoHttpReqFunc,
+		getCookie GetCookieFunc,
+		maxTries int,
+	) (*GenerateResult, error)
+
+	// GenerateSensorData is Session.GenerateSensorData.
+	GenerateSensorData(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error)
+
+	// GeneratePixelPayload is Session.GeneratePixelPayload.
+	GeneratePixelPayload(ctx context.Context, req *PixelSolveRequest) (*PixelSolveResponse, error)
+
+	// GenerateSecCptPayload is Session.GenerateSecCptPayload.
+	GenerateSecCptPayload(ctx context.Context, req *SecCptSolveRequest) (*SecCptSolveResponse, error)
+}
+
+var _ Generator = Session{}
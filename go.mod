@@ -1,3 +1,5 @@
 module github.com/SolarSystems-Software/akamai-sdk-go
 
 go 1.20
+
+require github.com/andybalholm/brotli v1.1.0
@@ -0,0 +1,12 @@
+package akamai
+
+import "regexp"
+
+var htmlExpr = regexp.MustCompile(`(?i)<(?:html|!doctype|script)`)
+
+// LooksLikeHTML reports if the given body looks like an HTML document, i.e. if it contains an `<html`,
+// `<!doctype` or `<script` tag (case-insensitive). This is a cheap heuristic to detect pages that return
+// JSON, an interstitial or an empty body instead of the expected document.
+func LooksLikeHTML(body []byte) bool {
+	return htmlExpr.Match(body)
+}
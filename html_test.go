@@ -0,0 +1,17 @@
+package akamai
+
+import "testing"
+
+func TestLooksLikeHTML(t *testing.T) {
+	for _, body := range []string{testPageBody, "<!DOCTYPE html><p>Hello</p>", "<script>var a;</script>"} {
+		if !LooksLikeHTML([]byte(body)) {
+			t.Fatal("HTML body reported as not HTML:", body)
+		}
+	}
+
+	for _, body := range []string{"", `{"message":"Hello"}`, "Hello, world!"} {
+		if LooksLikeHTML([]byte(body)) {
+			t.Fatal("non-HTML body reported as HTML:", body)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package akamai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -23,6 +24,8 @@ func (op HttpReqOp) String() string {
 		return "OpGetPixelChallengeScript"
 	case OpPostPixelPayload:
 		return "OpPostPixelPayload"
+	case OpPostSecCpt:
+		return "OpPostSecCpt"
 	default:
 		return ""
 	}
@@ -45,6 +48,11 @@ const (
 	// This operation only occurs if the script exists AND the challenge isn't already solved.
 	// See Session.Generate for more information.
 	OpPostPixelPayload
+
+	// OpPostSecCpt sends a POST request with a payload to solve the sec_cpt challenge.
+	// This operation only occurs if the challenge is present on the page. Implementations
+	// should set the Content-Type HTTP request header to "application/json".
+	OpPostSecCpt
 )
 
 // HttpOpError is a generic HTTP request failure. It contains no information about the actual
@@ -68,19 +76,85 @@ func (e BadStatusCodeError) Error() string {
 	return fmt.Sprintf("akamai-sdk-go: bad status HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
 }
 
+// ErrBodyTooLarge is an error caused by LimitedReadAll if the body is larger than the limit. It is also
+// caused by Session.Generate if a response body is larger than GenerateConfig.MaxBodyBytes, and by the API
+// methods of Session if the SolarSystems API responds with an unexpectedly large body.
+var ErrBodyTooLarge = errors.New("akamai-sdk-go: body too large")
+
+// LimitedReadAll is like io.ReadAll, but reads at most max bytes from r. If r has more than max bytes,
+// reading stops and ErrBodyTooLarge is returned, along with the bytes read so far. This allows DoHttpReqFunc
+// implementations to protect against pathological response bodies; see ReqContext.MaxBodyBytes.
+//
+// LimitedReadAll panics if max < 0.
+func LimitedReadAll(r io.Reader, max int64) ([]byte, error) {
+	if max < 0 {
+		panic("akamai-sdk-go: max < 0")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return body, err
+	}
+	if int64(len(body)) > max {
+		return body[:max], ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+// maxDrainBytes is the maximum number of unread response body bytes closeBody discards. Larger remainders
+// are not worth reading just to reuse the connection.
+const maxDrainBytes = 64 << 10
+
+// closeBody discards the unread remainder of body, up to maxDrainBytes, and closes it. Draining the body
+// allows the HTTP client to reuse the connection, e.g. after a read was interrupted by a cancelled context.
+func closeBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainBytes))
+	_ = body.Close()
+}
+
+// isSuccessStatus reports if statusCode is in the 2xx success range.
+func isSuccessStatus(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
+
+// StatusCodeFromError returns the status code of the first BadStatusCodeError found in the tree of err
+// (see errors.As), such as the errors returned by Session.Generate. ok is false if there is none.
+func StatusCodeFromError(err error) (statusCode int, ok bool) {
+	var badStatusErr BadStatusCodeError
+	if errors.As(err, &badStatusErr) {
+		return badStatusErr.StatusCode, true
+	}
+	return 0, false
+}
+
+// IsBadStatus reports if the tree of err contains a BadStatusCodeError with the given status code.
+// See StatusCodeFromError.
+func IsBadStatus(err error, statusCode int) bool {
+	code, ok := StatusCodeFromError(err)
+	return ok && code == statusCode
+}
+
 // DoHttpReqFunc makes an HTTP request to the provided request URL with the given request method
 // and request body. The implementation should return the status code, response body,
 // and an error (if any). Implementations MUST return an empty slice for the response body if
 // the body is empty. Returning a nil body if the returned error is nil will cause a panic when
 // used in functions like Session.Generate. Implementations should also close the response body
-// to prevent resource leaking.
+// to prevent resource leaking. The response body MUST be decompressed; see DecompressBody.
 //
 // The returned error MUST be nil unless an error occurred executing the HTTP request.
 // Implementations MUST NOT return an error due to an undesirable HTTP status code; functions
 // like Session.Generate handle this automatically.
 //
+// Implementations MUST honor the cancellation of ctx, aborting the request and returning ctx.Err() (or an
+// error wrapping it) once ctx is done. Besides the cancellation of the context passed to Session.Generate,
+// the requests of its concurrent steps share a context that is cancelled as soon as one step fails if
+// GenerateConfig.FailFast is set; implementations ignoring ctx keep the other steps running until their
+// requests complete. NewHTTPDoer honors ctx.
+//
 // Implementations can use the op parameter to differentiate different types of requests.
 // This is how implementations should decide on which headers to set and which order to set them in.
+// Session.Generate and its variants also store a ReqContext in ctx, which includes the URL of the page
+// the request is made for; see ReqContextFromContext.
 //
 // Functions of this type are implemented by the caller to allow full control over HTTP requests
 // for the caller. The main feature of this is to allow callers to use their own TLS fingerprint
@@ -0,0 +1,135 @@
+package akamai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// NewHTTPDoer creates a DoHttpReqFunc and a GetCookieFunc backed by the given net/http client and its
+// cookie jar. This is the simplest way to use Session.Generate for callers that do not need a custom
+// TLS fingerprint or header ordering.
+//
+// headersByOp are the HTTP request headers to set for each operation. It may be nil. Unless headersByOp
+// specifies otherwise, the Content-Type HTTP request header is set to "application/x-www-form-urlencoded"
+// for OpPostPixelPayload and to "application/json" for OpPostSecCpt, as required by Akamai Bot Manager.
+// The returned functions never modify headersByOp; callers must not modify it after calling NewHTTPDoer.
+//
+// Response bodies are read in full, unless Session.Generate limits their size with GenerateConfig.MaxBodyBytes.
+//
+// NewHTTPDoer panics if client == nil or client.Jar == nil.
+func NewHTTPDoer(client *http.Client, headersByOp map[HttpReqOp]http.Header) (DoHttpReqFunc, GetCookieFunc) {
+	if client == nil {
+		panic("akamai-sdk-go: nil client passed to NewHTTPDoer")
+	}
+	if client.Jar == nil {
+		panic("akamai-sdk-go: client without cookie jar passed to NewHTTPDoer")
+	}
+
+	doHttpReq := func(
+		ctx context.Context,
+		op HttpReqOp,
+		requestUrl,
+		requestMethod string,
+		requestBody io.Reader,
+	) (statusCode int, responseBody []byte, err error) {
+		request, err := http.NewRequestWithContext(ctx, requestMethod, requestUrl, requestBody)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case OpPostPixelPayload:
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		case OpPostSecCpt:
+			request.Header.Set("Content-Type", "application/json")
+		}
+		for name, values := range headersByOp[op] {
+			request.Header[name] = append([]string(nil), values...)
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return 0, nil, err
+		}
+		defer closeBody(response.Body)
+
+		var body []byte
+		if rc, _ := ReqContextFromContext(ctx); rc.MaxBodyBytes > 0 {
+			body, err = LimitedReadAll(response.Body, rc.MaxBodyBytes)
+		} else {
+			body, err = io.ReadAll(response.Body)
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+
+		return response.StatusCode, body, nil
+	}
+
+	getCookie := func(u *url.URL, name string) string {
+		for _, cookie := range client.Jar.Cookies(u) {
+			if cookie.Name == name {
+				return cookie.Value
+			}
+		}
+
+		return ""
+	}
+
+	return doHttpReq, getCookie
+}
+
+// WithRequestHeaders sets the HTTP request headers Session.GenerateWithJar sets for each operation, like the
+// headersByOp argument of NewHTTPDoer. headersByOp is copied, so callers may modify it afterwards.
+func WithRequestHeaders(headersByOp map[HttpReqOp]http.Header) SessionOption {
+	headers := make(map[HttpReqOp]http.Header, len(headersByOp))
+	for op, header := range headersByOp {
+		headers[op] = header.Clone()
+	}
+	return func(session *Session) {
+		session.requestHeaders = headers
+	}
+}
+
+// GenerateWithJar is like Generate, but makes the requests with the given net/http client and reads the
+// cookies from its cookie jar, using NewHTTPDoer. This is the recommended way to use Generate for callers
+// that do not need a custom TLS fingerprint or header ordering; other callers should use Generate with
+// their own DoHttpReqFunc and GetCookieFunc.
+//
+// The headers set with WithRequestHeaders are sent with each request. Unless they specify otherwise, the
+// User-Agent HTTP request header is set to userAgent (or the default user agent of the session, if empty),
+// as the generated sensor data must match the user agent of the requests.
+//
+// GenerateWithJar panics if client == nil or client.Jar == nil, or under the same conditions as Generate.
+func (session Session) GenerateWithJar(
+	ctx context.Context,
+	userAgent,
+	pageUrl string,
+	client *http.Client,
+	maxTries int,
+) error {
+	if client == nil {
+		panic("akamai-sdk-go: nil client passed to GenerateWithJar")
+	}
+	if client.Jar == nil {
+		panic("akamai-sdk-go: client without cookie jar passed to GenerateWithJar")
+	}
+
+	userAgent = session.resolveUserAgent(userAgent)
+	headersByOp := make(map[HttpReqOp]http.Header)
+	for op := OpGetPage; op <= OpPostSecCpt; op++ {
+		header := session.requestHeaders[op].Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		if header.Get("User-Agent") == "" {
+			header.Set("User-Agent", userAgent)
+		}
+		headersByOp[op] = header
+	}
+
+	doHttpReq, getCookie := NewHTTPDoer(client, headersByOp)
+	return session.Generate(ctx, userAgent, pageUrl, doHttpReq, getCookie, maxTries)
+}
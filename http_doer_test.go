@@ -0,0 +1,117 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestNewHTTPDoer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "_abck", Value: "abc~-1~def"})
+		_, _ = w.Write([]byte(r.Header.Get("Content-Type") + "|" + r.Header.Get("User-Agent")))
+	}))
+	defer server.Close()
+
+	jar, _ := cookiejar.New(nil)
+	doHttpReq, getCookie := NewHTTPDoer(&http.Client{Jar: jar}, map[HttpReqOp]http.Header{
+		OpPostPixelPayload: {"User-Agent": {testUserAgent}},
+	})
+
+	statusCode, body, err := doHttpReq(context.Background(), OpPostPixelPayload, server.URL, http.MethodPost, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusOK {
+		t.Fatal("unexpected status code:", statusCode)
+	}
+	if v := string(body); v != "application/x-www-form-urlencoded|"+testUserAgent {
+		t.Fatal("unexpected headers:", v)
+	}
+
+	u, _ := url.Parse(server.URL)
+	if v := getCookie(u, "_abck"); v != "abc~-1~def" {
+		t.Fatal("unexpected _abck cookie:", v)
+	}
+	if v := getCookie(u, "bm_sz"); v != "" {
+		t.Fatal("unexpected bm_sz cookie:", v)
+	}
+}
+
+func TestNewHTTPDoerMaxBodyBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	jar, _ := cookiejar.New(nil)
+	doHttpReq, _ := NewHTTPDoer(&http.Client{Jar: jar}, nil)
+
+	ctx := context.WithValue(context.Background(), reqContextKey{}, ReqContext{MaxBodyBytes: 4})
+	if _, _, err := doHttpReq(ctx, OpGetPage, server.URL, http.MethodGet, nil); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatal("expected ErrBodyTooLarge, got:", err)
+	}
+	if _, body, err := doHttpReq(context.Background(), OpGetPage, server.URL, http.MethodGet, nil); err != nil || string(body) != "hello" {
+		t.Fatalf("unexpected result: %q, %v", body, err)
+	}
+}
+
+func TestGenerateWithJar(t *testing.T) {
+	var mu sync.Mutex
+	var userAgents, languages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		languages = append(languages, r.Header.Get("Accept-Language"))
+		mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/product":
+			_, _ = w.Write([]byte(`<html><script src="/aBc-dEf/gHi"></script></html>`))
+		case r.URL.Path == "/aBc-dEf/gHi" && r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(testSdkScript))
+		case r.URL.Path == "/aBc-dEf/gHi" && r.Method == http.MethodPost:
+			http.SetCookie(w, &http.Cookie{Name: "_abck", Value: testValidAbck, Path: "/"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	session, api := newTestSession(t, WithRequestHeaders(map[HttpReqOp]http.Header{
+		OpGetPage: {"Accept-Language": {"en-US"}},
+	}))
+	jar, _ := cookiejar.New(nil)
+	if err := session.GenerateWithJar(context.Background(), testUserAgent, server.URL+"/product", &http.Client{Jar: jar}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := api.sensorCalls.Load(); v != 1 {
+		t.Fatal("expected 1 sensor API call, got:", v)
+	}
+	if len(userAgents) != 3 {
+		t.Fatal("expected 3 requests, got:", len(userAgents))
+	}
+	for i, userAgent := range userAgents {
+		if userAgent != testUserAgent {
+			t.Fatal("unexpected User-Agent header:", userAgent)
+		}
+		expected := ""
+		if i == 0 {
+			expected = "en-US"
+		}
+		if languages[i] != expected {
+			t.Fatalf("unexpected Accept-Language header of request %d: %q", i, languages[i])
+		}
+	}
+
+	u, _ := url.Parse(server.URL)
+	if v := jar.Cookies(u); len(v) != 1 || v[0].Value != testValidAbck {
+		t.Fatal("unexpected cookies:", v)
+	}
+}
@@ -0,0 +1,52 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStatusCodeFromError(t *testing.T) {
+	session, _ := newTestSession(t)
+	browser := &testBrowser{pixelStatus: http.StatusForbidden}
+
+	err := session.Generate(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		1,
+	)
+	if statusCode, ok := StatusCodeFromError(err); !ok || statusCode != http.StatusForbidden {
+		t.Fatalf("unexpected status code for %v: %d, %t", err, statusCode, ok)
+	}
+	if !IsBadStatus(err, http.StatusForbidden) || IsBadStatus(err, http.StatusServiceUnavailable) {
+		t.Fatal("unexpected IsBadStatus result for:", err)
+	}
+
+	joined := errors.Join(HttpOpError{Op: OpGetPage}, BadStatusCodeError{StatusCode: http.StatusServiceUnavailable})
+	if !IsBadStatus(joined, http.StatusServiceUnavailable) {
+		t.Fatal("IsBadStatus == false on joined error")
+	}
+	if _, ok := StatusCodeFromError(errors.New("foo")); ok {
+		t.Fatal("ok == true on unrelated error")
+	}
+	if IsBadStatus(nil, 0) {
+		t.Fatal("IsBadStatus == true on nil error")
+	}
+}
+
+func TestLimitedReadAll(t *testing.T) {
+	if body, err := LimitedReadAll(strings.NewReader("hello"), 5); err != nil || string(body) != "hello" {
+		t.Fatalf("unexpected result: %q, %v", body, err)
+	}
+	if body, err := LimitedReadAll(strings.NewReader("hello"), 4); !errors.Is(err, ErrBodyTooLarge) || string(body) != "hell" {
+		t.Fatalf("unexpected result: %q, %v", body, err)
+	}
+	if body, err := LimitedReadAll(strings.NewReader(""), 0); err != nil || len(body) != 0 {
+		t.Fatalf("unexpected result: %q, %v", body, err)
+	}
+}
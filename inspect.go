@@ -0,0 +1,105 @@
+package akamai
+
+import "net/url"
+
+// PageInfo describes the Akamai Bot Manager resources of a page; see InspectPage.
+// Fields of resources that were not found are zero values.
+type PageInfo struct {
+	// ScriptPath is the web SDK script reference as found in the page, which is a path or an absolute URL.
+	// See GetScriptURL.
+	ScriptPath string
+
+	// ScriptURL is the absolute URL of the web SDK script.
+	ScriptURL string
+
+	// PixelChallengePresent reports if the page contains the pixel challenge.
+	PixelChallengePresent bool
+
+	// PixelScriptURL is the URL of the pixel challenge script.
+	PixelScriptURL string
+
+	// PixelPostURL is the URL to post the pixel challenge payload to. See PixelPostURL.
+	PixelPostURL string
+
+	// PixelHtmlVar is the pixel challenge HTML variable. See GetPixelChallengeHtmlVarFor.
+	PixelHtmlVar int
+
+	// Warnings are diagnostic messages about resources that were expected but not found, in a fixed order.
+	// Each is one of the PageWarning constants. As protected pages normally contain the web SDK script,
+	// warnings for pages known to be protected are a strong signal that the parsers of the SDK are outdated.
+	Warnings []string
+}
+
+// Warnings reported in PageInfo.Warnings.
+const (
+	// PageWarningNotHTML is reported if the page does not look like an HTML document. See LooksLikeHTML.
+	PageWarningNotHTML = "page is not an HTML document"
+
+	// PageWarningNoScript is reported if the page neither references nor inlines the web SDK script.
+	PageWarningNoScript = "no SDK script path found"
+
+	// PageWarningNoPixelChallenge is reported if the page does not contain the pixel challenge.
+	PageWarningNoPixelChallenge = "no pixel challenge found"
+
+	// PageWarningNoPixelHtmlVar is reported if the page contains the pixel challenge script, but not its
+	// HTML variable, so the challenge cannot be solved.
+	PageWarningNoPixelHtmlVar = "no pixel challenge HTML variable found"
+)
+
+// InspectPage parses the Akamai Bot Manager resources of the given page without making any request,
+// which is useful to analyze pages. pageUrl is the URL the page was fetched from, which is used to
+// make relative URLs absolute.
+//
+// The error returned is non-nil if pageUrl is not a valid absolute URL, in which case it is
+// ErrInvalidPageURL or the parsing error. Resources missing from the page are not errors, but are reported
+// in PageInfo.Warnings.
+func InspectPage(pageBody []byte, pageUrl string) (*PageInfo, error) {
+	u, err := url.Parse(pageUrl)
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, ErrInvalidPageURL
+	}
+
+	var info PageInfo
+	if !LooksLikeHTML(pageBody) {
+		info.Warnings = append(info.Warnings, PageWarningNotHTML)
+	}
+
+	if ok, scriptUrl, _ := GetScriptURL(pageBody); ok {
+		info.ScriptPath = scriptUrl
+		info.ScriptURL, _ = AbsolutizeURL(u, scriptUrl)
+	} else if ok, _ = GetInlineScript(pageBody); !ok {
+		info.Warnings = append(info.Warnings, PageWarningNoScript)
+	}
+
+	info.PixelChallengePresent, info.PixelScriptURL, info.PixelPostURL = GetPixelChallengeScriptURL(pageBody)
+	if info.PixelChallengePresent {
+		if info.PixelHtmlVar, err = GetPixelChallengeHtmlVarFor(pageBody, info.PixelScriptURL); err != nil {
+			info.Warnings = append(info.Warnings, PageWarningNoPixelHtmlVar)
+		}
+		info.PixelScriptURL = AbsolutizePixelURL(u, info.PixelScriptURL)
+		info.PixelPostURL = AbsolutizePixelURL(u, info.PixelPostURL)
+	} else {
+		info.Warnings = append(info.Warnings, PageWarningNoPixelChallenge)
+	}
+	return &info, nil
+}
+
+// HasAkamaiProtection reports if the given page contains an Akamai Bot Manager web SDK script (referenced
+// or inlined), a pixel challenge or a sec_cpt challenge. Callers can use it to skip Session.Generate for
+// pages that are not protected, saving API credits and requests.
+func HasAkamaiProtection(pageBody []byte) bool {
+	if ok, _, _ := GetScriptURL(pageBody); ok {
+		return true
+	}
+	if ok, _, _ := GetPixelChallengeScriptURL(pageBody); ok {
+		return true
+	}
+	if ok, _ := GetSecCptChallenge(pageBody); ok {
+		return true
+	}
+	ok, _ := GetInlineScript(pageBody)
+	return ok
+}
@@ -0,0 +1,74 @@
+package akamai
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInspectPage(t *testing.T) {
+	info, err := InspectPage([]byte(testPageBody), testPageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := PageInfo{
+		ScriptPath:            "/aBc-dEf/gHi",
+		ScriptURL:             "https://www.example.com/aBc-dEf/gHi",
+		PixelChallengePresent: true,
+		PixelScriptURL:        "https://www.example.com/akam/13/1a2b3c",
+		PixelPostURL:          "https://www.example.com/akam/13/pixel_1a2b3c",
+		PixelHtmlVar:          1234,
+	}
+	if !reflect.DeepEqual(*info, expected) {
+		t.Fatalf("unexpected page info: %+v", *info)
+	}
+
+	expected = PageInfo{Warnings: []string{PageWarningNoScript, PageWarningNoPixelChallenge}}
+	if info, err = InspectPage([]byte("<html></html>"), testPageURL); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(*info, expected) {
+		t.Fatalf("unexpected page info: %+v", *info)
+	}
+
+	page := `<html><script>var _acxj=[];</script><script src="https://www.example.com/akam/13/1a2b3c"></script></html>`
+	if info, err = InspectPage([]byte(page), testPageURL); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(info.Warnings, []string{PageWarningNoPixelHtmlVar}) {
+		t.Fatal("unexpected warnings:", info.Warnings)
+	}
+
+	if info, err = InspectPage([]byte(`{"message":"Hello, world!"}`), testPageURL); err != nil {
+		t.Fatal(err)
+	} else if len(info.Warnings) != 3 || info.Warnings[0] != PageWarningNotHTML {
+		t.Fatal("unexpected warnings:", info.Warnings)
+	}
+
+	if _, err = InspectPage([]byte(testPageBody), "/product"); !errors.Is(err, ErrInvalidPageURL) {
+		t.Fatal("expected ErrInvalidPageURL, got:", err)
+	}
+}
+
+func TestHasAkamaiProtection(t *testing.T) {
+	for _, page := range []string{
+		testPageBody,
+		`<html><script src="/aBc-dEf/gHi"></script></html>`,
+		`<html><script type="text/javascript" src="https://www.example.com/akam/13/1a2b3c" defer></script></html>`,
+		`<html><script src="/_sec/cp_challenge/ak-challenge-4-3.js"></script></html>`,
+		`<html><script>var _acxj=[];</script></html>`,
+	} {
+		if !HasAkamaiProtection([]byte(page)) {
+			t.Fatal("HasAkamaiProtection == false on protected page:", page)
+		}
+	}
+
+	for _, page := range []string{
+		``,
+		`<html><body>Hello, world!</body></html>`,
+		`<html><script src="/static/app.js"></script><script>window.foo = 1;</script></html>`,
+	} {
+		if HasAkamaiProtection([]byte(page)) {
+			t.Fatal("HasAkamaiProtection == true on unprotected page:", page)
+		}
+	}
+}
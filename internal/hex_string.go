@@ -1,20 +1,70 @@
 package internal
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf16"
 )
 
-// FromHexString converts a string with hexadecimal characters to normal characters.
+// FromHexString converts a string with hexadecimal escape sequences to normal characters.
+//
+// Both `\xNN` (two hexadecimal digits) and `\uNNNN` (four hexadecimal digits) escape sequences are supported
+// and can be mixed. A UTF-16 surrogate pair encoded as two consecutive `\uNNNN` sequences is combined into
+// a single character. Characters outside escape sequences are kept as is.
 func FromHexString(s string) (string, error) {
 	var builder strings.Builder
-	parts := strings.Split(s, "\\")[1:] // first element is always a blank string
-	for _, char := range parts {
-		i, err := strconv.ParseInt(strings.Replace(char, "x", "", 1), 16, 64)
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			builder.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		r, n, err := parseEscape(s[i:])
 		if err != nil {
 			return "", err
 		}
-		builder.WriteRune(rune(i))
+		i += n
+
+		// Combine a high surrogate with the following low surrogate
+		if utf16.IsSurrogate(r) {
+			if low, m, err := parseEscape(s[i:]); err == nil && m == 6 {
+				if combined := utf16.DecodeRune(r, low); combined != unicode.ReplacementChar {
+					r = combined
+					i += m
+				}
+			}
+		}
+		builder.WriteRune(r)
 	}
 	return builder.String(), nil
 }
+
+// parseEscape parses the escape sequence at the start of s. It returns the escaped character and the
+// length of the escape sequence.
+func parseEscape(s string) (r rune, n int, err error) {
+	if len(s) < 2 || s[0] != '\\' {
+		return 0, 0, fmt.Errorf("expected escape sequence: %q", s)
+	}
+
+	var digits int
+	switch s[1] {
+	case 'x':
+		digits = 2
+	case 'u':
+		digits = 4
+	default:
+		return 0, 0, fmt.Errorf("unsupported escape sequence: %q", s[:2])
+	}
+	if len(s) < 2+digits {
+		return 0, 0, fmt.Errorf("incomplete escape sequence: %q", s)
+	}
+
+	v, err := strconv.ParseUint(s[2:2+digits], 16, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return rune(v), 2 + digits, nil
+}
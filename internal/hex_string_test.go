@@ -0,0 +1,29 @@
+package internal
+
+import "testing"
+
+func TestFromHexString(t *testing.T) {
+	tests := map[string]string{
+		``:                 "",
+		`\x61\x62\x63`:     "abc",
+		`\u0061\u00e9\x62`: "a\u00e9b",
+		`\x41\u4e2d\x42`:   "A\u4e2dB",
+		`\ud83d\ude00\x21`: "\U0001f600!",
+		`\x61bc`:           "abc",
+		`A\ud83d\ude00B`:   "A\U0001f600B",
+		`\ud83d\x61`:       "\ufffda",
+	}
+	for input, expected := range tests {
+		if v, err := FromHexString(input); err != nil {
+			t.Fatalf("err != nil on valid input %s: %s", input, err)
+		} else if v != expected {
+			t.Fatalf("unexpected value for %s: %q", input, v)
+		}
+	}
+
+	for _, input := range []string{`\x6`, `\u004`, `\n`, `\xzz`, `\`} {
+		if _, err := FromHexString(input); err == nil {
+			t.Fatal("err == nil on invalid input:", input)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package akamai
+
+import "context"
+
+// WithMaxConcurrentAPIRequests limits the number of in-flight SolarSystems API requests made by the session
+// to n. Requests exceeding the limit block until another request completes or their context is done.
+// The limit is shared by all copies of the session.
+//
+// WithMaxConcurrentAPIRequests panics if n <= 0.
+func WithMaxConcurrentAPIRequests(n int) SessionOption {
+	if n <= 0 {
+		panic("akamai-sdk-go: n <= 0")
+	}
+
+	return func(session *Session) {
+		session.apiSlots = make(chan struct{}, n)
+	}
+}
+
+// acquireAPISlot blocks until an API request can be made according to the session's concurrency limit,
+// or until ctx is done. Callers must call releaseAPISlot once the request is complete if the returned
+// error is nil.
+func (session Session) acquireAPISlot(ctx context.Context) error {
+	if session.apiSlots == nil {
+		return nil
+	}
+
+	select {
+	case session.apiSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseAPISlot releases a slot acquired with acquireAPISlot.
+func (session Session) releaseAPISlot() {
+	if session.apiSlots != nil {
+		<-session.apiSlots
+	}
+}
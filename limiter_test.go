@@ -0,0 +1,49 @@
+package akamai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithMaxConcurrentAPIRequests(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			v := maxInFlight.Load()
+			if n <= v || maxInFlight.CompareAndSwap(v, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL), WithMaxConcurrentAPIRequests(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := session.GenerateSensorData(context.Background(), testGenerateRequest()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v := maxInFlight.Load(); v > 2 {
+		t.Fatal("expected at most 2 concurrent API requests, got:", v)
+	}
+}
@@ -0,0 +1,42 @@
+package akamai
+
+import "sync"
+
+// Logger receives debug messages about the progress of Session.Generate.
+// *log.Logger from the standard library can be adapted with a one-line wrapper.
+type Logger interface {
+	// Debugf logs a message with the given format and arguments, like fmt.Printf.
+	Debugf(format string, args ...any)
+}
+
+// lockedLogger is a Logger that serializes calls to another Logger.
+type lockedLogger struct {
+	mu     sync.Mutex
+	logger Logger
+}
+
+func (l *lockedLogger) Debugf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.logger.Debugf(format, args...)
+}
+
+// WithLogger sets the Logger of the session. Calls to the logger are serialized, so implementations do not
+// need to be safe for usage by multiple goroutines unless they are shared with other code.
+func WithLogger(logger Logger) SessionOption {
+	return func(session *Session) {
+		if logger == nil {
+			session.logger = nil
+		} else {
+			session.logger = &lockedLogger{logger: logger}
+		}
+	}
+}
+
+// debugf logs a debug message with the session's logger, if any.
+func (session Session) debugf(format string, args ...any) {
+	if session.logger != nil {
+		session.logger.Debugf(format, args...)
+	}
+}
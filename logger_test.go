@@ -0,0 +1,40 @@
+package akamai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testLogger is a Logger writing to a strings.Builder. It is not safe for usage by multiple goroutines.
+type testLogger struct {
+	strings.Builder
+}
+
+func (l *testLogger) Debugf(format string, args ...any) {
+	l.WriteString(fmt.Sprintf(format, args...) + "\n")
+}
+
+func TestLogger(t *testing.T) {
+	logger := &testLogger{}
+	session, _ := newTestSession(t, WithLogger(logger))
+	browser := &testBrowser{}
+
+	if err := session.Generate(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		2,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, message := range []string{"fetched page", "detected web SDK version 1.75", "pixel challenge present"} {
+		if !strings.Contains(logger.String(), message) {
+			t.Fatalf("expected log message %q, got:\n%s", message, logger.String())
+		}
+	}
+}
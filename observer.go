@@ -0,0 +1,63 @@
+package akamai
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Observer receives timing information about the requests made by a Session. Implementations must be
+// safe for usage by multiple goroutines, as Session.Generate calls them from multiple goroutines.
+type Observer interface {
+	// OnAPIRequest is called after each request to the SolarSystems API completes, including retries.
+	// endpoint is the path of the API endpoint. statusCode is zero if no response was received.
+	OnAPIRequest(endpoint string, dur time.Duration, statusCode int)
+
+	// OnHTTPOp is called after each call to the DoHttpReqFunc passed to Session.Generate returns.
+	OnHTTPOp(op HttpReqOp, dur time.Duration, statusCode int, err error)
+}
+
+// PayloadObserver is an optional interface an Observer can implement to also receive the sizes of the
+// payloads generated by the SolarSystems API, e.g. to alert on unexpectedly small payloads.
+type PayloadObserver interface {
+	// OnPayload is called after each payload is generated. endpoint is the path of the API endpoint, and
+	// size is the length of the payload in bytes.
+	OnPayload(endpoint string, size int)
+}
+
+// WithObserver sets the Observer of the session. If observer also implements PayloadObserver, it is
+// notified of payload sizes as well.
+func WithObserver(observer Observer) SessionOption {
+	return func(session *Session) {
+		session.observer = observer
+	}
+}
+
+// observeHttpReq wraps doHttpReq to report each call to the session's Observer, if any.
+func (session Session) observeHttpReq(doHttpReq DoHttpReqFunc) DoHttpReqFunc {
+	observer := session.observer
+	if observer == nil {
+		return doHttpReq
+	}
+
+	return func(
+		ctx context.Context,
+		op HttpReqOp,
+		requestUrl,
+		requestMethod string,
+		requestBody io.Reader,
+	) (statusCode int, responseBody []byte, err error) {
+		start := session.now()
+		statusCode, responseBody, err = doHttpReq(ctx, op, requestUrl, requestMethod, requestBody)
+		observer.OnHTTPOp(op, session.since(start), statusCode, err)
+		return
+	}
+}
+
+// observePayload reports the size of a payload generated by the given endpoint to the session's Observer,
+// if it implements PayloadObserver.
+func (session Session) observePayload(endpoint apiEndpoint, payload string) {
+	if observer, ok := session.observer.(PayloadObserver); ok {
+		observer.OnPayload(endpoint.path, len(payload))
+	}
+}
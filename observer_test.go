@@ -0,0 +1,109 @@
+package akamai
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testObserver is an Observer counting the observed requests.
+type testObserver struct {
+	mu          sync.Mutex
+	apiRequests map[string]int
+	httpOps     map[HttpReqOp]int
+}
+
+func (o *testObserver) OnAPIRequest(endpoint string, _ time.Duration, _ int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.apiRequests[endpoint]++
+}
+
+func (o *testObserver) OnHTTPOp(op HttpReqOp, _ time.Duration, _ int, _ error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.httpOps[op]++
+}
+
+func TestObserver(t *testing.T) {
+	observer := &testObserver{
+		apiRequests: make(map[string]int),
+		httpOps:     make(map[HttpReqOp]int),
+	}
+	session, _ := newTestSession(t, WithObserver(observer))
+	browser := &testBrowser{}
+
+	if err := session.Generate(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		2,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := observer.apiRequests[sensorEndpoint.path]; v != 2 {
+		t.Fatal("expected 2 sensor API requests, got:", v)
+	}
+	if v := observer.apiRequests[pixelEndpoint.path]; v != 1 {
+		t.Fatal("expected 1 pixel API request, got:", v)
+	}
+	if v := observer.httpOps[OpPostSensorData]; v != 2 {
+		t.Fatal("expected 2 OpPostSensorData, got:", v)
+	}
+	observed := 0
+	for _, count := range observer.httpOps {
+		observed += count
+	}
+	if observed != len(browser.ops) {
+		t.Fatalf("observed %d ops, expected %d", observed, len(browser.ops))
+	}
+}
+
+// testPayloadObserver is a testObserver also recording the observed payload sizes.
+type testPayloadObserver struct {
+	testObserver
+	payloadSizes map[string][]int
+}
+
+func (o *testPayloadObserver) OnPayload(endpoint string, size int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.payloadSizes[endpoint] = append(o.payloadSizes[endpoint], size)
+}
+
+func TestPayloadObserver(t *testing.T) {
+	observer := &testPayloadObserver{
+		testObserver: testObserver{
+			apiRequests: make(map[string]int),
+			httpOps:     make(map[HttpReqOp]int),
+		},
+		payloadSizes: make(map[string][]int),
+	}
+	session, _ := newTestSession(t, WithObserver(observer))
+	browser := &testBrowser{}
+
+	if err := session.Generate(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		2,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := observer.payloadSizes[sensorEndpoint.path]; len(v) != 2 || v[0] != len("payload") || v[1] != len("payload") {
+		t.Fatal("unexpected sensor payload sizes:", v)
+	}
+	if v := observer.payloadSizes[pixelEndpoint.path]; len(v) != 1 || v[0] != len("payload") {
+		t.Fatal("unexpected pixel payload sizes:", v)
+	}
+}
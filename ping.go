@@ -0,0 +1,57 @@
+package akamai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Ping checks that the SolarSystems API is reachable and accepts the session's API key, e.g. in a readiness
+// probe. It returns nil on success, ErrCircuitOpen if the session's circuit breaker is open, an error
+// matching ErrUnauthorized or ErrForbidden (see errors.Is) if the API key is rejected, or the error of the
+// failed request otherwise.
+//
+// As the API has no dedicated health endpoint, Ping sends an empty sensor data request, and any 4xx HTTP
+// status code other than 401 Unauthorized, 403 Forbidden and 429 Too Many Requests in response is treated
+// as success. A rate limited API is reported as a RateLimitError, as it cannot serve requests.
+//
+// The sensor data endpoint is billed per successful request. The API is expected to reject the empty
+// request, which uses no credits, but this is not guaranteed: if the API accepts it, Ping succeeds, the
+// request is counted by APICallCount and it may use a credit like any other sensor data request.
+// Ping is subject to the session's circuit breaker and concurrency limit, and its outcome is recorded by
+// the circuit breaker, but it is never retried.
+func (session Session) Ping(ctx context.Context) error {
+	allowed, trial := true, false
+	if session.breaker != nil {
+		allowed, trial = session.breaker.allow(session.now())
+	}
+	if !allowed {
+		return ErrCircuitOpen
+	}
+	if err := session.acquireAPISlot(ctx); err != nil {
+		if session.breaker != nil {
+			session.breaker.done(ctx, trial, err, session.now())
+		}
+		return err
+	}
+	err := session.sendAPIRequest(ctx, sensorEndpoint, []byte("{}"), new(json.RawMessage))
+	session.releaseAPISlot()
+	if session.breaker != nil {
+		session.breaker.done(ctx, trial, err, session.now())
+	}
+	if err == nil {
+		// The API accepted the empty request.
+		session.countAPICall()
+		return nil
+	}
+
+	var apiErr ApiOperationError
+	if errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusBadRequest &&
+		apiErr.StatusCode < http.StatusInternalServerError && apiErr.StatusCode != http.StatusUnauthorized &&
+		apiErr.StatusCode != http.StatusForbidden && apiErr.StatusCode != http.StatusTooManyRequests {
+		// The API is reachable, but rejected the empty request.
+		return nil
+	}
+	return err
+}
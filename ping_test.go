@@ -0,0 +1,87 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != sensorEndpoint.path {
+			t.Error("unexpected path:", r.URL.Path)
+		}
+		switch r.Header.Get("x-api-key") {
+		case "key":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"invalid request"}`))
+		case "gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "accepted":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"payload":"payload"}`))
+		case "expired":
+			w.WriteHeader(http.StatusForbidden)
+		case "down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("key", WithBaseURL(server.URL))
+	if err := session.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v := session.APICallCount(); v != 0 {
+		t.Fatal("expected no counted API calls, got:", v)
+	}
+
+	if err := NewSessionWithOptions("gone", WithBaseURL(server.URL)).Ping(context.Background()); err != nil {
+		t.Fatal("expected 4xx status code to be treated as reachable, got:", err)
+	}
+
+	var rateLimitErr RateLimitError
+	if err := NewSessionWithOptions("limited", WithBaseURL(server.URL)).Ping(context.Background()); !errors.As(err, &rateLimitErr) {
+		t.Fatal("expected RateLimitError, got:", err)
+	}
+	accepted := NewSessionWithOptions("accepted", WithBaseURL(server.URL))
+	if err := accepted.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v := accepted.APICallCount(); v != 1 {
+		t.Fatal("expected the accepted request to be counted, got:", v)
+	}
+
+	if err := NewSessionWithOptions("", WithBaseURL(server.URL)).Ping(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Fatal("expected ErrUnauthorized, got:", err)
+	}
+	if err := NewSessionWithOptions("expired", WithBaseURL(server.URL)).Ping(context.Background()); !errors.Is(err, ErrForbidden) {
+		t.Fatal("expected ErrForbidden, got:", err)
+	}
+
+	var apiErr ApiOperationError
+	err := NewSessionWithOptions("down", WithBaseURL(server.URL)).Ping(context.Background())
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("expected ApiOperationError, got:", err)
+	}
+
+	breakerSession := NewSessionWithOptions("down", WithBaseURL(server.URL), WithCircuitBreaker(1, time.Hour))
+	if err = breakerSession.Ping(context.Background()); !errors.As(err, &apiErr) {
+		t.Fatal("expected ApiOperationError, got:", err)
+	}
+	if err = breakerSession.Ping(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected ErrCircuitOpen, got:", err)
+	}
+
+	server.Close()
+	if err = session.Ping(context.Background()); err == nil {
+		t.Fatal("err == nil on unreachable API")
+	}
+}
@@ -3,13 +3,14 @@ package akamai
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/SolarSystems-Software/akamai-sdk-go/internal"
-	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -20,51 +21,216 @@ var (
 	ErrPixelHtmlVarNotFound = errors.New("akamai-sdk-go: pixel HTML var not found")
 )
 
+// maxPixelHtmlVar is the largest plausible pixel challenge HTML variable. The variable is a 32-bit integer
+// in the pages served by Akamai Bot Manager, so larger values indicate that something else was matched.
+const maxPixelHtmlVar = math.MaxInt32
+
 // GetPixelChallengeHtmlVar gets the required pixel challenge variable from the given HTML code src.
+// If the page contains the variable more than once, the first occurrence is used; see
+// GetPixelChallengeHtmlVarFor to get the variable of a specific pixel challenge.
 //
-// The error returned is non-nil if the value was not found. In this case, the returned error
-// is ErrPixelHtmlVarNotFound. There may be multiple errors; callers can use errors.Unwrap
-// to get all the errors.
+// The error returned is non-nil if the value was not found or is not within the plausible range of
+// 1 to math.MaxInt32. In this case, the returned error is ErrPixelHtmlVarNotFound. There may be multiple
+// errors; callers can use errors.Unwrap to get all the errors.
 func GetPixelChallengeHtmlVar(src []byte) (int, error) {
 	matches := pixelHtmlExpr.FindSubmatch(src)
 	if len(matches) < 2 {
 		return 0, ErrPixelHtmlVarNotFound
 	}
+	return parsePixelHtmlVar(matches[1])
+}
 
-	if v, err := strconv.Atoi(string(matches[1])); err == nil {
-		return v, nil
-	} else {
+// GetAllPixelChallengeHtmlVars is like GetPixelChallengeHtmlVar, but returns the values of every occurrence
+// of the variable in the page, in document order. Values that are not valid are skipped. The returned slice
+// is empty if the page does not contain the variable.
+func GetAllPixelChallengeHtmlVars(src []byte) []int {
+	var values []int
+	for _, matches := range pixelHtmlExpr.FindAllSubmatch(src, -1) {
+		if v, err := parsePixelHtmlVar(matches[1]); err == nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// GetPixelChallengeHtmlVarFor is like GetPixelChallengeHtmlVar, but gets the variable associated with the
+// pixel challenge script at scriptUrl, as returned by GetAllPixelChallengeScriptURLs. Pages may contain
+// the variable more than once, e.g. a stale value cached with an older part of the page next to a fresh one;
+// the variable belonging to a challenge is declared right before its script. The last occurrence before
+// the script is used, or the first one after it if there is none. If the script is not found in src,
+// GetPixelChallengeHtmlVarFor behaves like GetPixelChallengeHtmlVar.
+func GetPixelChallengeHtmlVarFor(src []byte, scriptUrl string) (int, error) {
+	scriptIndex := bytes.Index(src, []byte(`"`+scriptUrl+`"`))
+	if scriptIndex < 0 {
+		return GetPixelChallengeHtmlVar(src)
+	}
+
+	var value []byte
+	for _, indices := range pixelHtmlExpr.FindAllSubmatchIndex(src, -1) {
+		if value != nil && indices[0] > scriptIndex {
+			break
+		}
+		value = src[indices[2]:indices[3]]
+	}
+	if value == nil {
+		return 0, ErrPixelHtmlVarNotFound
+	}
+	return parsePixelHtmlVar(value)
+}
+
+// parsePixelHtmlVar parses a pixel challenge HTML variable matched by pixelHtmlExpr and checks that it is
+// within the plausible range.
+func parsePixelHtmlVar(value []byte) (int, error) {
+	v, err := strconv.Atoi(string(value))
+	if err != nil {
 		return 0, errors.Join(ErrPixelHtmlVarNotFound, err)
 	}
+	if v < 1 || v > maxPixelHtmlVar {
+		return 0, errors.Join(ErrPixelHtmlVarNotFound, fmt.Errorf("value out of range: %d", v))
+	}
+	return v, nil
 }
 
-var pixelScriptUrlExpr = regexp.MustCompile(`(?i)src="(https?://.+/akam/\d+/\w+)"`)
+var pixelScriptUrlExpr = regexp.MustCompile(`(?i)src="((?:https?:)?//[^"\s]+?/akam/\d+/(\w+)(?:\?[^"]*)?)"`)
 
 // GetPixelChallengeScriptURL gets the script URL of the pixel challenge script and the URL
-// to post a generated payload to from the given HTML code src.
+// to post a generated payload to from the given HTML code src. If the page contains multiple pixel
+// challenges, the first one is returned; see GetAllPixelChallengeScriptURLs.
+//
+// The script URL may include a query string, which is kept in both URLs. It may also be protocol-relative
+// (`//host/akam/...`), in which case both URLs must be resolved against the page URL; see AbsolutizePixelURL.
 //
 // ok is true if the URL was found. Callers should treat ok == false as an error.
 // See GetPixelChallengeHtmlVar for more information.
 func GetPixelChallengeScriptURL(src []byte) (ok bool, scriptUrl, postUrl string) {
-	matches := pixelScriptUrlExpr.FindSubmatch(src)
-	if len(matches) < 2 {
+	locations := GetAllPixelChallengeScriptURLs(src)
+	if len(locations) == 0 {
 		return
 	}
+	return true, locations[0].ScriptURL, locations[0].PostURL
+}
 
-	scriptUrl = string(matches[1])
+// PixelChallengeLocation is the location of a pixel challenge in a page.
+type PixelChallengeLocation struct {
+	// ScriptURL is the URL of the pixel challenge script.
+	ScriptURL string
+
+	// PostURL is the URL to post the generated payload to. See PixelPostURL.
+	PostURL string
+}
+
+// GetAllPixelChallengeScriptURLs is like GetPixelChallengeScriptURL, but returns every pixel challenge
+// of the page, in document order. Scripts included more than once are only returned once. The returned
+// slice is empty if the page does not contain the pixel challenge.
+func GetAllPixelChallengeScriptURLs(src []byte) []PixelChallengeLocation {
+	return GetAllPixelChallengeScriptURLsMatching(src, nil)
+}
+
+// GetAllPixelChallengeScriptURLsMatching is like GetAllPixelChallengeScriptURLs, but also finds pixel
+// challenge scripts matching any of the given patterns, for websites proxying the script under a first-party
+// path without `/akam/`. The first capturing group of a pattern is the script URL, or the whole match if the
+// pattern has none; e.g. `src="(/assets/px/\w+)"`. Script URLs whose last path segment starts with `pixel_`
+// are ignored, as they are the post URLs of the challenges.
+//
+// The post URLs are derived with PixelPostURL, which may not apply to non-standard paths; callers should
+// set GenerateConfig.PixelPostURLFunc for such websites.
+func GetAllPixelChallengeScriptURLsMatching(src []byte, patterns []*regexp.Regexp) []PixelChallengeLocation {
+	type match struct {
+		offset    int
+		scriptUrl string
+	}
+	var matches []match
+	for _, expr := range append([]*regexp.Regexp{pixelScriptUrlExpr}, patterns...) {
+		for _, indices := range expr.FindAllSubmatchIndex(src, -1) {
+			start, end := indices[0], indices[1]
+			if len(indices) >= 4 && indices[2] >= 0 {
+				start, end = indices[2], indices[3]
+			}
+			matches = append(matches, match{offset: start, scriptUrl: string(src[start:end])})
+		}
+	}
+	if len(patterns) > 0 {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].offset < matches[j].offset
+		})
+	}
+
+	var locations []PixelChallengeLocation
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		// The <noscript> fallback image of the challenge uses the post URL, which also matches.
+		if isPixelPostURL(match.scriptUrl) || seen[match.scriptUrl] {
+			continue
+		}
+		seen[match.scriptUrl] = true
+		locations = append(locations, PixelChallengeLocation{
+			ScriptURL: match.scriptUrl,
+			PostURL:   PixelPostURL(match.scriptUrl),
+		})
+	}
+	return locations
+}
+
+// isPixelPostURL reports if the last path segment of the given pixel challenge URL starts with `pixel_`.
+func isPixelPostURL(pixelUrl string) bool {
+	if i := strings.IndexByte(pixelUrl, '?'); i >= 0 {
+		pixelUrl = pixelUrl[:i]
+	}
+	segment := pixelUrl[strings.LastIndexByte(pixelUrl, '/')+1:]
+	return strings.HasPrefix(strings.ToLower(segment), "pixel_")
+}
+
+// WithPixelURLPatterns makes Session.Generate and its variants also find pixel challenge scripts matching
+// any of the given patterns, in addition to the default pattern. See GetAllPixelChallengeScriptURLsMatching.
+//
+// WithPixelURLPatterns panics if a pattern is nil.
+func WithPixelURLPatterns(patterns []*regexp.Regexp) SessionOption {
+	for _, pattern := range patterns {
+		if pattern == nil {
+			panic("akamai-sdk-go: nil pattern passed to WithPixelURLPatterns")
+		}
+	}
+	patterns = append([]*regexp.Regexp(nil), patterns...)
+
+	return func(session *Session) {
+		session.pixelPatterns = patterns
+	}
+}
+
+// AbsolutizePixelURL resolves a pixel challenge URL returned by GetPixelChallengeScriptURL, which may be
+// protocol-relative, against the URL of the page it was found in, using AbsolutizeURL. Invalid URLs are
+// returned unchanged.
+func AbsolutizePixelURL(pageUrl *url.URL, pixelUrl string) string {
+	if absolute, err := AbsolutizeURL(pageUrl, pixelUrl); err == nil {
+		return absolute
+	}
+	return pixelUrl
+}
+
+// IsPixelAlreadySolved reports if the given HTTP status code of a GET request to the pixel challenge
+// script indicates that the challenge is already solved. Akamai Bot Manager responds with 404 Not Found
+// instead of the script once the challenge is solved.
+func IsPixelAlreadySolved(statusCode int) bool {
+	return statusCode == http.StatusNotFound
+}
+
+// PixelPostURL derives the URL to post a pixel challenge payload to from the given pixel challenge
+// script URL, by prefixing the last path segment with `pixel_`. The query string, if any, is kept.
+// This is the default used by GetPixelChallengeScriptURL; see GenerateConfig.PixelPostURLFunc.
+func PixelPostURL(scriptUrl string) string {
+	var query string
+	if i := strings.IndexByte(scriptUrl, '?'); i >= 0 {
+		scriptUrl, query = scriptUrl[:i], scriptUrl[i:]
+	}
 
-	// Create postUrl
 	parts := strings.Split(scriptUrl, "/")
 	parts[len(parts)-1] = "pixel_" + parts[len(parts)-1]
-	postUrl = strings.Join(parts, "/")
-
-	ok = true
-	return
+	return strings.Join(parts, "/") + query
 }
 
 var (
-	pixelScriptVarExpr         = regexp.MustCompile(`g=_\[(\d+)]`)
-	pixelScriptStringArrayExpr = regexp.MustCompile(`(?i)var _=\[(.+)];`)
+	pixelScriptVarExpr         = regexp.MustCompile(`[\w$]+=([\w$]+)\[(\d+)]`)
+	pixelScriptStringArrayExpr = regexp.MustCompile(`(?i)var ([\w$]+)=\[(.+?)];`)
 	pixelScriptStringsExpr     = regexp.MustCompile(`(?i)"([^",]*)"`)
 
 	ErrPixelScriptVarNotFound = errors.New("akamai-sdk-go: script var not found")
@@ -73,31 +239,46 @@ var (
 // GetPixelChallengeScriptVar gets the dynamic pixel challenge variable from the given JavaScript code src.
 // The JavaScript code should be the pixel script.
 //
+// The variable is the string at index n of an array of encoded strings declared as `var Y=[...];`, which
+// is assigned with `X=Y[n]`. The variable names differ between scripts; the first assignment indexing
+// a declared array of strings is used.
+//
 // err is nil if the value of the variable was found. In all other cases, ErrPixelScriptVarNotFound is the
 // returned error, which contains another error explaining in detail why the call resulted in an error.
 // Callers can use errors.Unwrap to get the more detailed error.
 func GetPixelChallengeScriptVar(src []byte) (string, error) {
-	// Find array index
-	index := pixelScriptVarExpr.FindSubmatch(src)
-	if length := len(index); length != 2 {
-		return "", errors.Join(ErrPixelScriptVarNotFound, fmt.Errorf("len(index) expected 2, got: %d", length))
+	// Find arrays with encoded strings by name
+	arrays := make(map[string][]byte)
+	for _, arrayDeclaration := range pixelScriptStringArrayExpr.FindAllSubmatch(src, -1) {
+		name := string(arrayDeclaration[1])
+		if arrays[name] == nil && pixelScriptStringsExpr.Match(arrayDeclaration[2]) {
+			arrays[name] = arrayDeclaration[2]
+		}
 	}
-	stringIndex, err := strconv.Atoi(string(index[1]))
-	if err != nil {
-		return "", errors.Join(ErrPixelScriptVarNotFound, err)
+	if len(arrays) == 0 {
+		return "", errors.Join(ErrPixelScriptVarNotFound, errors.New("no array declaration found"))
 	}
 
-	// Find array with encoded strings
-	arrayDeclaration := pixelScriptStringArrayExpr.FindSubmatch(src)
-	if length := len(arrayDeclaration); length < 2 {
-		return "", errors.Join(
-			ErrPixelScriptVarNotFound,
-			fmt.Errorf("len(arrayDeclaration) expected 2, got: %d", length),
-		)
+	// Find array index
+	var array []byte
+	stringIndex := 0
+	for _, index := range pixelScriptVarExpr.FindAllSubmatch(src, -1) {
+		if array = arrays[string(index[1])]; array == nil {
+			continue
+		}
+
+		var err error
+		if stringIndex, err = strconv.Atoi(string(index[2])); err != nil {
+			return "", errors.Join(ErrPixelScriptVarNotFound, err)
+		}
+		break
+	}
+	if array == nil {
+		return "", errors.Join(ErrPixelScriptVarNotFound, errors.New("no index into a declared array found"))
 	}
 
 	// The raw strings
-	rawStrings := pixelScriptStringsExpr.FindAllSubmatch(arrayDeclaration[1], -1)
+	rawStrings := pixelScriptStringsExpr.FindAllSubmatch(array, -1)
 	// bounds check to prevent a possible panic
 	if stringIndex >= len(rawStrings) {
 		return "", errors.Join(
@@ -145,44 +326,10 @@ type PixelSolveResponse struct {
 // logic and processing automatically. This method is intended for callers who wish to interact with
 // the API directly.
 func (session Session) GeneratePixelPayload(ctx context.Context, req *PixelSolveRequest) (*PixelSolveResponse, error) {
-	encoded, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-
-	request, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		"https://akamai.publicapis.solarsystems.software/v1/pixel/generate",
-		bytes.NewBuffer(encoded),
-	)
-	if err != nil {
-		return nil, err
-	}
-	request.Header.Set("User-Agent", "SolarSystems akamai-sdk-go")
-	request.Header.Set("Content-Type", "application/json")
-
-	response, err := session.client.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	defer response.Body.Close()
-
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if response.StatusCode != http.StatusCreated {
-		return nil, ApiOperationError{
-			StatusCode: response.StatusCode,
-			Message:    GetMessageFromErrorResponse(body),
-		}
-	}
-
 	var resp PixelSolveResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
+	if err := session.doAPIRequest(ctx, pixelEndpoint, req, &resp); err != nil {
 		return nil, err
 	}
+	session.observePayload(pixelEndpoint, resp.Payload)
 	return &resp, nil
 }
@@ -1,6 +1,13 @@
 package akamai
 
-import "testing"
+import (
+	"errors"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+)
 
 func TestGetPixelChallengeHtmlVar(t *testing.T) {
 	const (
@@ -17,4 +24,183 @@ func TestGetPixelChallengeHtmlVar(t *testing.T) {
 	if _, err := GetPixelChallengeHtmlVar([]byte(invalidInput)); err == nil {
 		t.Fatal("err == nil on valid input")
 	}
+
+	for _, input := range []string{`bazadebezolkohpepadr="0"`, `bazadebezolkohpepadr="2147483648"`} {
+		if _, err := GetPixelChallengeHtmlVar([]byte(input)); !errors.Is(err, ErrPixelHtmlVarNotFound) {
+			t.Fatal("expected ErrPixelHtmlVarNotFound on out of range input, got:", err)
+		}
+	}
+}
+
+func TestGetPixelChallengeHtmlVarFor(t *testing.T) {
+	src, err := os.ReadFile("tests/pixel_two_vars.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := GetAllPixelChallengeHtmlVars(src); !reflect.DeepEqual(v, []int{1111, 2147}) {
+		t.Fatal("unexpected HTML vars:", v)
+	}
+	if v := GetAllPixelChallengeHtmlVars([]byte(`bazadebezolkohpepadr="0" bazadebezolkohpepadr="5"`)); !reflect.DeepEqual(v, []int{5}) {
+		t.Fatal("unexpected HTML vars:", v)
+	}
+	if v := GetAllPixelChallengeHtmlVars([]byte(`<html></html>`)); len(v) != 0 {
+		t.Fatal("unexpected HTML vars:", v)
+	}
+
+	// The variable right before the script is used, not the first one of the page
+	if v, err := GetPixelChallengeHtmlVarFor(src, "https://www.example.com/akam/13/7f8e9d"); err != nil {
+		t.Fatal(err)
+	} else if v != 2147 {
+		t.Fatal("v != 2147:", v)
+	}
+	if v, err := GetPixelChallengeHtmlVar(src); err != nil || v != 1111 {
+		t.Fatal("unexpected first HTML var:", v, err)
+	}
+
+	// Without a variable before the script, the first one after it is used
+	after := `<script src="https://www.example.com/akam/13/1a2b3c"></script>` +
+		`<script>bazadebezolkohpepadr="300"</script><script>bazadebezolkohpepadr="400"</script>`
+	if v, err := GetPixelChallengeHtmlVarFor([]byte(after), "https://www.example.com/akam/13/1a2b3c"); err != nil || v != 300 {
+		t.Fatal("unexpected HTML var:", v, err)
+	}
+
+	// Unknown scripts fall back to the first variable
+	if v, err := GetPixelChallengeHtmlVarFor(src, "https://www.example.com/akam/13/000000"); err != nil || v != 1111 {
+		t.Fatal("unexpected HTML var:", v, err)
+	}
+	if _, err := GetPixelChallengeHtmlVarFor([]byte(`<script src="https://www.example.com/akam/13/1a2b3c"></script>`),
+		"https://www.example.com/akam/13/1a2b3c"); !errors.Is(err, ErrPixelHtmlVarNotFound) {
+		t.Fatal("expected ErrPixelHtmlVarNotFound, got:", err)
+	}
+
+	info, err := InspectPage(src, testPageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PixelHtmlVar != 2147 {
+		t.Fatal("unexpected page info HTML var:", info.PixelHtmlVar)
+	}
+}
+
+func TestGetPixelChallengeScriptURL(t *testing.T) {
+	ok, scriptUrl, postUrl := GetPixelChallengeScriptURL([]byte(testPageBody))
+	if !ok {
+		t.Fatal("ok == false on valid input")
+	}
+	if scriptUrl != "https://www.example.com/akam/13/1a2b3c" {
+		t.Fatal("unexpected script URL:", scriptUrl)
+	}
+	if postUrl != "https://www.example.com/akam/13/pixel_1a2b3c" {
+		t.Fatal("unexpected post URL:", postUrl)
+	}
+
+	tests := []struct {
+		src       string
+		scriptUrl string
+		postUrl   string
+	}{
+		{
+			`<script type="text/javascript" src="//www.example.com/akam/13/1a2b3c" defer></script>`,
+			"//www.example.com/akam/13/1a2b3c",
+			"//www.example.com/akam/13/pixel_1a2b3c",
+		},
+		{
+			`<noscript><img src="https://www.example.com/akam/13/pixel_1a2b3c?a=dD0x"></noscript>` +
+				`<script type="text/javascript" src="https://www.example.com/akam/13/1a2b3c?v=2" defer></script>`,
+			"https://www.example.com/akam/13/1a2b3c?v=2",
+			"https://www.example.com/akam/13/pixel_1a2b3c?v=2",
+		},
+	}
+	for _, test := range tests {
+		ok, scriptUrl, postUrl := GetPixelChallengeScriptURL([]byte(test.src))
+		if !ok {
+			t.Fatal("ok == false on valid input:", test.src)
+		}
+		if scriptUrl != test.scriptUrl || postUrl != test.postUrl {
+			t.Fatalf("unexpected URLs for %s: %s, %s", test.src, scriptUrl, postUrl)
+		}
+	}
+
+	if ok, _, _ := GetPixelChallengeScriptURL([]byte(`<img src="https://www.example.com/akam/13/pixel_1a2b3c?a=dD0x">`)); ok {
+		t.Fatal("ok == true on pixel challenge image")
+	}
+
+	pageUrl, _ := url.Parse(testPageURL)
+	if v := AbsolutizePixelURL(pageUrl, "//www.example.com/akam/13/1a2b3c"); v != "https://www.example.com/akam/13/1a2b3c" {
+		t.Fatal("unexpected absolute URL:", v)
+	}
+}
+
+func TestGetAllPixelChallengeScriptURLs(t *testing.T) {
+	src := testPageBody + `
+<script type="text/javascript" src="https://www.example.com/akam/13/1a2b3c" defer></script>
+<script type="text/javascript" src="https://www.example.com/akam/13/4d5e6f" defer></script>`
+	expected := []PixelChallengeLocation{
+		{ScriptURL: "https://www.example.com/akam/13/1a2b3c", PostURL: "https://www.example.com/akam/13/pixel_1a2b3c"},
+		{ScriptURL: "https://www.example.com/akam/13/4d5e6f", PostURL: "https://www.example.com/akam/13/pixel_4d5e6f"},
+	}
+	if locations := GetAllPixelChallengeScriptURLs([]byte(src)); !reflect.DeepEqual(locations, expected) {
+		t.Fatal("unexpected locations:", locations)
+	}
+
+	if locations := GetAllPixelChallengeScriptURLs([]byte("<html></html>")); len(locations) != 0 {
+		t.Fatal("unexpected locations:", locations)
+	}
+}
+
+func TestGetAllPixelChallengeScriptURLsMatching(t *testing.T) {
+	src, err := os.ReadFile("tests/proxied_pixel.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locations := GetAllPixelChallengeScriptURLs(src); len(locations) != 0 {
+		t.Fatal("unexpected locations without patterns:", locations)
+	}
+
+	patterns := []*regexp.Regexp{regexp.MustCompile(`src="(/static-assets/px/\w+)(?:\?[^"]*)?"`)}
+	expected := []PixelChallengeLocation{
+		{ScriptURL: "/static-assets/px/9a8b7c", PostURL: "/static-assets/px/pixel_9a8b7c"},
+	}
+	if locations := GetAllPixelChallengeScriptURLsMatching(src, patterns); !reflect.DeepEqual(locations, expected) {
+		t.Fatal("unexpected locations:", locations)
+	}
+	if v, err := GetPixelChallengeHtmlVarFor(src, expected[0].ScriptURL); err != nil || v != 5678 {
+		t.Fatal("unexpected HTML var:", v, err)
+	}
+
+	// Locations found by the default pattern and by additional patterns are in document order
+	mixed := append([]byte(`<script src="https://www.example.com/akam/13/1a2b3c"></script>`), src...)
+	locations := GetAllPixelChallengeScriptURLsMatching(mixed, append(patterns, regexp.MustCompile(`/static-assets/px/\w+`)))
+	if len(locations) != 2 || locations[0].ScriptURL != "https://www.example.com/akam/13/1a2b3c" ||
+		locations[1].ScriptURL != "/static-assets/px/9a8b7c" {
+		t.Fatal("unexpected locations:", locations)
+	}
+}
+
+func TestGetPixelChallengeScriptVar(t *testing.T) {
+	tests := map[string]string{
+		testPixelScript: "def",
+		`var z=["\x61\x62\x63","\x64\x65\x66","\x67"];function f(){h=z[2];}`: "g",
+		`var a=[1,2];var $b=["\x61","\x62"];c=a[0];d=$b[1];`:                 "b",
+		`var _=["\x61\x62\x63"];var z=["\x78\x79\x7a"];h=z[0];g=_[0];`:       "xyz",
+	}
+	for src, expected := range tests {
+		if v, err := GetPixelChallengeScriptVar([]byte(src)); err != nil {
+			t.Fatalf("err != nil on valid input %s: %s", src, err)
+		} else if v != expected {
+			t.Fatalf("unexpected value for %s: %s", src, v)
+		}
+	}
+
+	for _, src := range []string{
+		``,
+		`var z=["\x61"];`,
+		`h=z[0];`,
+		`var z=["\x61"];h=z[5];`,
+	} {
+		if _, err := GetPixelChallengeScriptVar([]byte(src)); !errors.Is(err, ErrPixelScriptVarNotFound) {
+			t.Fatalf("expected ErrPixelScriptVarNotFound for %s, got: %s", src, err)
+		}
+	}
 }
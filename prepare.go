@@ -0,0 +1,161 @@
+package akamai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Content types of the POST requests described by PreparedRequest.
+const (
+	// SensorDataContentType is the Content-Type of sensor data POST requests sent by browsers.
+	SensorDataContentType = "text/plain;charset=UTF-8"
+
+	// PixelPayloadContentType is the Content-Type required for pixel challenge payload POST requests.
+	PixelPayloadContentType = "application/x-www-form-urlencoded"
+
+	// SecCptPayloadContentType is the Content-Type required for sec_cpt challenge payload POST requests.
+	SecCptPayloadContentType = "application/json"
+)
+
+// PrepareInput are the resources Session.Prepare needs besides the page, which the caller fetches itself.
+type PrepareInput struct {
+	// Version is the web SDK version, e.g. GenerateResult.DetectedVersion of an earlier call. If it is set,
+	// SdkScript is not used.
+	Version Version
+
+	// SdkScript is the body of the web SDK script of the page; see InspectPage. It is used to detect the web
+	// SDK version and, if DetectSensorEndpoint is set, the sensor data endpoint (see GetSensorEndpoint). It
+	// is not needed if the page inlines the script.
+	SdkScript []byte
+
+	// PixelScripts are the bodies of the pixel challenge scripts of the page, keyed by absolute URL
+	// (see GetAllPixelChallengeScriptURLs and AbsolutizePixelURL). Challenges without a script are skipped.
+	PixelScripts map[string][]byte
+
+	// Cookies are the values of the Akamai Bot Manager cookies of the page (see AkamaiCookieNames), keyed by
+	// name, e.g. as returned by ParseAkamaiCookies. Sensor data and sec_cpt payloads are generated for them.
+	Cookies map[string]string
+
+	// SolveSecCpt enables preparing the sec_cpt challenge payload; see GenerateConfig.SolveSecCpt.
+	SolveSecCpt bool
+
+	// DetectSensorEndpoint enables posting sensor data to the endpoint embedded in SdkScript; see
+	// GenerateConfig.DetectSensorEndpoint.
+	DetectSensorEndpoint bool
+}
+
+// PreparedRequest is a POST request prepared by Session.Prepare for the caller to send.
+type PreparedRequest struct {
+	// URL is the absolute URL to post to.
+	URL string
+
+	// ContentType is the value of the Content-Type HTTP request header to send. Akamai Bot Manager rejects
+	// pixel and sec_cpt challenge payloads sent with another Content-Type.
+	ContentType string
+
+	// Body is the request body.
+	Body []byte
+}
+
+// PreparedPayloads are the payloads generated by Session.Prepare. Requests for resources missing from the
+// page are nil or empty.
+type PreparedPayloads struct {
+	// Version is the web SDK version the sensor data was generated for. It is empty if the page does not
+	// contain the web SDK script.
+	Version Version
+
+	// Sensor is the sensor data POST request.
+	Sensor *PreparedRequest
+
+	// Pixel are the pixel challenge payload POST requests, in document order.
+	Pixel []PreparedRequest
+
+	// SecCpt is the sec_cpt challenge payload POST request. It is only prepared if PrepareInput.SolveSecCpt
+	// is set.
+	SecCpt *PreparedRequest
+}
+
+// Prepare parses the given page and generates the payloads of its web SDK script and challenges like
+// GenerateFromPage, but returns the POST requests to send instead of sending them. This allows callers
+// whose HTTP stack cannot be expressed with a DoHttpReqFunc to send the requests themselves. The scripts
+// and cookies the payloads are generated for are given by input. pageUrl must be the absolute URL the page
+// was fetched from.
+//
+// Unlike Generate, Prepare generates a single sensor data payload, as it cannot observe the _abck cookie
+// set in response. Callers should post it, read the new cookies and call Prepare again until the _abck
+// cookie is valid (see IsCookieValid), caching Version in input.
+//
+// The error returned is non-nil under the same conditions as Generate. If the page references a web SDK
+// script and neither input.Version nor input.SdkScript is set, it is ErrEmptyScript.
+func (session Session) Prepare(
+	ctx context.Context,
+	userAgent string,
+	pageBody []byte,
+	pageUrl string,
+	input PrepareInput,
+) (*PreparedPayloads, error) {
+	var payloads PreparedPayloads
+	var mu sync.Mutex
+	doHttpReq := func(
+		_ context.Context,
+		op HttpReqOp,
+		requestUrl,
+		_ string,
+		requestBody io.Reader,
+	) (statusCode int, responseBody []byte, err error) {
+		switch op {
+		case OpGetPage:
+			return http.StatusOK, pageBody, nil
+		case OpGetSdkScript:
+			return http.StatusOK, append([]byte{}, input.SdkScript...), nil
+		case OpGetPixelChallengeScript:
+			if script, ok := input.PixelScripts[requestUrl]; ok {
+				return http.StatusOK, script, nil
+			}
+			// Skip the challenge like an already solved one.
+			return http.StatusNotFound, []byte{}, nil
+		}
+
+		body, err := io.ReadAll(requestBody)
+		if err != nil {
+			return 0, nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch op {
+		case OpPostSensorData:
+			payloads.Sensor = &PreparedRequest{URL: requestUrl, ContentType: SensorDataContentType, Body: body}
+		case OpPostPixelPayload:
+			request := PreparedRequest{URL: requestUrl, ContentType: PixelPayloadContentType, Body: body}
+			payloads.Pixel = append(payloads.Pixel, request)
+		case OpPostSecCpt:
+			payloads.SecCpt = &PreparedRequest{URL: requestUrl, ContentType: SecCptPayloadContentType, Body: body}
+		}
+		return http.StatusOK, []byte{}, nil
+	}
+	getCookie := func(_ *url.URL, name string) string {
+		return input.Cookies[name]
+	}
+
+	cfg := DefaultGenerateConfig()
+	cfg.SensorMaxTries = 1
+	cfg.ForceVersion = input.Version
+	cfg.Sequential = true
+	cfg.SolveSecCpt = input.SolveSecCpt
+	cfg.DetectSensorEndpoint = input.DetectSensorEndpoint
+	userAgent = session.resolveUserAgent(userAgent)
+	u, err := session.checkGenerateArgs(userAgent, pageUrl, doHttpReq, getCookie, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := session.generateFromPage(ctx, userAgent, pageUrl, u, pageBody, doHttpReq, getCookie, cfg)
+	if err != nil {
+		return nil, err
+	}
+	payloads.Version = result.DetectedVersion
+	return &payloads, nil
+}
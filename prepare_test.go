@@ -0,0 +1,59 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPrepare(t *testing.T) {
+	session, api := newTestSession(t)
+	payloads, err := session.Prepare(context.Background(), testUserAgent, []byte(testPageBody), testPageURL, PrepareInput{
+		SdkScript:    []byte(testSdkScript),
+		PixelScripts: map[string][]byte{"https://www.example.com/akam/13/1a2b3c": []byte(testPixelScript)},
+		Cookies:      map[string]string{"_abck": testInvalidAbck},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payloads.Version != Version175 {
+		t.Fatal("unexpected version:", payloads.Version)
+	}
+	if v := payloads.Sensor; v == nil || v.URL != "https://www.example.com/aBc-dEf/gHi" ||
+		v.ContentType != SensorDataContentType || string(v.Body) != string(SensorDataEnvelope("payload")) {
+		t.Fatalf("unexpected sensor data request: %+v", v)
+	}
+	if len(payloads.Pixel) != 1 {
+		t.Fatal("expected 1 pixel challenge request, got:", len(payloads.Pixel))
+	}
+	if v := payloads.Pixel[0]; v.URL != "https://www.example.com/akam/13/pixel_1a2b3c" ||
+		v.ContentType != PixelPayloadContentType || string(v.Body) != "payload" {
+		t.Fatalf("unexpected pixel challenge request: %+v", v)
+	}
+	if payloads.SecCpt != nil {
+		t.Fatalf("unexpected sec_cpt request: %+v", payloads.SecCpt)
+	}
+	if api.sensorCalls.Load() != 1 || api.pixelCalls.Load() != 1 {
+		t.Fatal("unexpected API calls:", api.sensorCalls.Load(), api.pixelCalls.Load())
+	}
+	if api.sensorRequests[0].Abck != testInvalidAbck {
+		t.Fatal("unexpected _abck cookie:", api.sensorRequests[0].Abck)
+	}
+
+	// Without pixel challenge scripts, the challenge is skipped; the version can be given instead of the script
+	payloads, err = session.Prepare(context.Background(), testUserAgent, []byte(testPageBody), testPageURL, PrepareInput{
+		Version: Version2,
+		Cookies: map[string]string{"bm_sz": "bm_sz-0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payloads.Version != Version2 || payloads.Sensor == nil || len(payloads.Pixel) != 0 {
+		t.Fatalf("unexpected payloads: %+v", payloads)
+	}
+
+	if _, err = session.Prepare(context.Background(), testUserAgent, []byte(testPageBody), testPageURL, PrepareInput{}); !errors.Is(err, ErrEmptyScript) {
+		t.Fatal("expected ErrEmptyScript, got:", err)
+	}
+}
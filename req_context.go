@@ -0,0 +1,121 @@
+package akamai
+
+import (
+	"context"
+	"io"
+)
+
+// ReqContext describes a request executed with a DoHttpReqFunc by Session.Generate and its variants.
+// Implementations of DoHttpReqFunc can get it with ReqContextFromContext, e.g. to route all requests
+// made for the same page through the same proxy.
+type ReqContext struct {
+	// Op is the operation of the request.
+	Op HttpReqOp
+
+	// PageURL is the URL of the page the request is made for, as passed to Session.Generate.
+	PageURL string
+
+	// URL is the URL of the request.
+	URL string
+
+	// Method is the HTTP method of the request.
+	Method string
+
+	// ContentType is the Content-Type the request body should be sent with, if the caller configured one,
+	// such as GenerateConfig.SensorContentType. If empty, implementations choose it based on Op as usual.
+	ContentType string
+
+	// MaxBodyBytes is the maximum size of the response body, from GenerateConfig.MaxBodyBytes. Larger bodies
+	// cause generation to fail with ErrBodyTooLarge, so implementations can stop reading them early with
+	// LimitedReadAll. If zero, there is no limit.
+	MaxBodyBytes int64
+}
+
+// reqContextKey is the context key of the ReqContext of a request.
+type reqContextKey struct{}
+
+// contentTypeKey is the context key of the ReqContext.ContentType of a request, set by generation.
+type contentTypeKey struct{}
+
+// ReqContextFromContext returns the ReqContext stored in the context passed to a DoHttpReqFunc by
+// Session.Generate and its variants. ok is false if ctx does not contain a ReqContext, e.g. if the
+// DoHttpReqFunc is called by other code.
+func ReqContextFromContext(ctx context.Context) (rc ReqContext, ok bool) {
+	rc, ok = ctx.Value(reqContextKey{}).(ReqContext)
+	return
+}
+
+// OpFromContext returns the HttpReqOp of the request the given context was created for by Session.Generate
+// and its variants. DoHttpReqFunc implementations that pass their context to the requests they make (like
+// the one returned by NewHTTPDoer) make it available to http.RoundTripper middleware, e.g. for logging or
+// metrics per operation. ok is false if ctx does not belong to such a request.
+func OpFromContext(ctx context.Context) (op HttpReqOp, ok bool) {
+	rc, ok := ReqContextFromContext(ctx)
+	return rc.Op, ok
+}
+
+// DoHttpReqWithContextFunc is like DoHttpReqFunc, but receives the request as a ReqContext.
+// Use AdaptDoHttpReqWithContext to pass one to Session.Generate and its variants.
+type DoHttpReqWithContextFunc func(
+	ctx context.Context,
+	rc ReqContext,
+	requestBody io.Reader,
+) (
+	statusCode int,
+	responseBody []byte,
+	err error,
+)
+
+// AdaptDoHttpReqWithContext adapts a DoHttpReqWithContextFunc to a DoHttpReqFunc. DoHttpReqFunc is unchanged,
+// so existing implementations keep working as is; implementations that need the page URL can either call
+// ReqContextFromContext themselves, or be written as a DoHttpReqWithContextFunc and adapted with this function.
+// If the context does not contain a ReqContext, one is built from the arguments with an empty PageURL.
+//
+// AdaptDoHttpReqWithContext panics if fn is nil.
+func AdaptDoHttpReqWithContext(fn DoHttpReqWithContextFunc) DoHttpReqFunc {
+	if fn == nil {
+		panic("akamai-sdk-go: nil DoHttpReqWithContextFunc passed to AdaptDoHttpReqWithContext")
+	}
+
+	return func(
+		ctx context.Context,
+		op HttpReqOp,
+		requestUrl,
+		requestMethod string,
+		requestBody io.Reader,
+	) (statusCode int, responseBody []byte, err error) {
+		rc, ok := ReqContextFromContext(ctx)
+		if !ok {
+			rc = ReqContext{Op: op, URL: requestUrl, Method: requestMethod}
+		}
+		return fn(ctx, rc, requestBody)
+	}
+}
+
+// withReqContext wraps doHttpReq to store the ReqContext of each request made for the page at pageUrl
+// in the context passed to it. If maxBodyBytes is positive, response bodies larger than it are rejected
+// with ErrBodyTooLarge.
+func withReqContext(doHttpReq DoHttpReqFunc, pageUrl string, maxBodyBytes int64) DoHttpReqFunc {
+	return func(
+		ctx context.Context,
+		op HttpReqOp,
+		requestUrl,
+		requestMethod string,
+		requestBody io.Reader,
+	) (statusCode int, responseBody []byte, err error) {
+		contentType, _ := ctx.Value(contentTypeKey{}).(string)
+		ctx = context.WithValue(ctx, reqContextKey{}, ReqContext{
+			Op:           op,
+			PageURL:      pageUrl,
+			URL:          requestUrl,
+			Method:       requestMethod,
+			ContentType:  contentType,
+			MaxBodyBytes: maxBodyBytes,
+		})
+		statusCode, responseBody, err = doHttpReq(ctx, op, requestUrl, requestMethod, requestBody)
+		if err == nil && maxBodyBytes > 0 && int64(len(responseBody)) > maxBodyBytes {
+			return statusCode, nil, ErrBodyTooLarge
+		}
+		return
+	}
+}
@@ -0,0 +1,94 @@
+package akamai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReqContext(t *testing.T) {
+	session, _ := newTestSession(t)
+	browser := &testBrowser{}
+
+	var mu sync.Mutex
+	var contexts []ReqContext
+	doHttpReq := AdaptDoHttpReqWithContext(func(
+		ctx context.Context,
+		rc ReqContext,
+		requestBody io.Reader,
+	) (int, []byte, error) {
+		mu.Lock()
+		contexts = append(contexts, rc)
+		mu.Unlock()
+		return browser.doHttpReq(ctx, rc.Op, rc.URL, rc.Method, requestBody)
+	})
+
+	if err := session.Generate(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		doHttpReq,
+		browser.getCookie,
+		1,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(contexts) != len(browser.ops) {
+		t.Fatalf("expected %d requests, got: %d", len(browser.ops), len(contexts))
+	}
+	for _, rc := range contexts {
+		if rc.PageURL != testPageURL {
+			t.Fatal("unexpected page URL:", rc.PageURL)
+		}
+		if rc.Op == OpGetSdkScript && rc.URL != "https://www.example.com/aBc-dEf/gHi" {
+			t.Fatal("unexpected web SDK script URL:", rc.URL)
+		}
+	}
+
+	if _, ok := ReqContextFromContext(context.Background()); ok {
+		t.Fatal("ok == true on context without ReqContext")
+	}
+}
+
+// opRoundTripper records the HttpReqOp of each request and responds with an empty body.
+type opRoundTripper struct {
+	mu  sync.Mutex
+	ops []HttpReqOp
+}
+
+func (rt *opRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if op, ok := OpFromContext(req.Context()); ok {
+		rt.mu.Lock()
+		rt.ops = append(rt.ops, op)
+		rt.mu.Unlock()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("<html></html>")),
+		Request:    req,
+	}, nil
+}
+
+func TestOpFromContext(t *testing.T) {
+	session, _ := newTestSession(t)
+	rt := &opRoundTripper{}
+	jar, _ := cookiejar.New(nil)
+	doHttpReq, getCookie := NewHTTPDoer(&http.Client{Transport: rt, Jar: jar}, nil)
+
+	if err := session.Generate(context.Background(), testUserAgent, testPageURL, doHttpReq, getCookie, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.ops) != 1 || rt.ops[0] != OpGetPage {
+		t.Fatal("unexpected ops:", rt.ops)
+	}
+
+	if _, ok := OpFromContext(context.Background()); ok {
+		t.Fatal("ok == true on context without op")
+	}
+}
@@ -0,0 +1,68 @@
+package akamai
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy describes how SolarSystems API requests failing with a transient HTTP status code
+// (429, 500, 502, 503 or 504) are retried. Only API requests are retried; requests made with a
+// DoHttpReqFunc are never retried.
+//
+// A RetryPolicy must not be modified after it is passed to a Session. It is then safe for usage by
+// multiple goroutines.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts per API request, including the first attempt.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. The delay doubles with every subsequent retry,
+	// and a random jitter of up to half the delay is subtracted from it.
+	BaseDelay time.Duration
+}
+
+// WithRetry sets the RetryPolicy of the session to retry API requests up to maxAttempts times
+// in total with exponential backoff starting at baseDelay.
+//
+// WithRetry panics if maxAttempts <= 0.
+func WithRetry(maxAttempts int, baseDelay time.Duration) SessionOption {
+	if maxAttempts <= 0 {
+		panic("akamai-sdk-go: maxAttempts <= 0")
+	}
+
+	policy := &RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   baseDelay,
+	}
+	return func(session *Session) {
+		session.retry = policy
+	}
+}
+
+// shouldRetry reports if a request that failed with the given status code on the given attempt
+// (starting at 1) should be retried. It is safe to call on a nil policy.
+func (policy *RetryPolicy) shouldRetry(attempt, statusCode int) bool {
+	if policy == nil || attempt >= policy.MaxAttempts {
+		return false
+	}
+
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// delay returns the delay to wait before retrying after the given failed attempt (starting at 1).
+func (policy *RetryPolicy) delay(attempt int) time.Duration {
+	d := policy.BaseDelay << (attempt - 1)
+	if d <= 0 {
+		return 0
+	}
+	return d - time.Duration(rand.Int63n(int64(d)/2+1))
+}
@@ -0,0 +1,61 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var calls, failures atomic.Int32
+	failures.Store(2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"abc"}`))
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL), WithRetry(3, time.Millisecond))
+	response, err := session.GenerateSensorData(context.Background(), testGenerateRequest())
+	if err != nil {
+		t.Fatal("err != nil after retrying:", err)
+	}
+	if response.Payload != "abc" {
+		t.Fatal("unexpected payload:", response.Payload)
+	}
+
+	calls.Store(0)
+	failures.Store(100)
+	var apiErr ApiOperationError
+	if _, err = session.GenerateSensorData(context.Background(), testGenerateRequest()); !errors.As(err, &apiErr) {
+		t.Fatal("expected ApiOperationError, got:", err)
+	} else if apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("unexpected status code:", apiErr.StatusCode)
+	}
+	if v := calls.Load(); v != 3 {
+		t.Fatal("expected 3 attempts, got:", v)
+	}
+}
+
+func TestRetryPolicyContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL), WithRetry(10, time.Hour))
+	if _, err := session.GenerateSensorData(ctx, testGenerateRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected context.DeadlineExceeded, got:", err)
+	}
+}
@@ -1,15 +1,282 @@
 package akamai
 
-import "regexp"
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
 
-var scriptPathExpr = regexp.MustCompile(`<script type="text/javascript"(?i:.*) src="((?i)[/\w\-]+)">`)
+var (
+	scriptPathExpr = regexp.MustCompile(`^[/\w\-]+$`)
+	scriptUrlExpr  = regexp.MustCompile(`^(?i:https?://[\w\-.]+(?::\d+)?)?[/\w\-]+$`)
+
+	// inlineScriptExpr matches identifiers and strings specific to the web SDK: the string array of version
+	// 1.75, the command queue of version 1.7, the bmak object and the JSON key sensor data is posted with.
+	inlineScriptExpr = regexp.MustCompile(`^var\s+(?:_acxj|_cf)\s*=|\bbmak\b|["']sensor_data["']`)
+)
 
 // GetScriptPath gets the Akamai Bot Manager web SDK path from the given HTML code src.
 // ok is true if the path was found, otherwise it is false.
+//
+// The SDK is recognized in any JavaScript <script> tag (one without a type attribute or with a JavaScript
+// type) regardless of attribute order or quote style. Pixel challenge scripts (see GetPixelChallengeScriptURL)
+// are never returned. If several scripts could be the SDK, the most Akamai-like one is returned, see
+// GetScriptPathCandidates.
 func GetScriptPath(src []byte) (ok bool, path string) {
-	matches := scriptPathExpr.FindSubmatch(src)
-	if ok = len(matches) >= 2; ok {
-		path = string(matches[1])
+	if candidates := GetScriptPathCandidates(src); len(candidates) > 0 {
+		return true, candidates[0]
+	}
+	return
+}
+
+// GetScriptPathCandidates gets every path in the given HTML code src that could be the Akamai Bot Manager
+// web SDK path, most Akamai-like first. Paths that are equally Akamai-like are kept in document order.
+//
+// The SDK is served from a path without a file extension made of random-looking segments that mix upper
+// and lower case letters or letters and digits, e.g. /Ho2tvp/sB7U/XuwD/AJ2g/Ax7iYv. Paths with more such
+// segments are more Akamai-like.
+func GetScriptPathCandidates(src []byte) (paths []string) {
+	for _, ref := range scriptSrcs(src) {
+		if scriptPathExpr.MatchString(ref) && !strings.Contains(ref, "/akam/") {
+			paths = append(paths, ref)
+		}
+	}
+	sortScriptCandidates(paths)
+	return
+}
+
+// GetScriptURL is like GetScriptPath, but also recognizes web SDK scripts referenced by an absolute
+// (possibly cross-origin) URL. absolute reports which form was found: if false, scriptUrl is a path
+// that must be resolved against the page URL.
+func GetScriptURL(src []byte) (ok bool, scriptUrl string, absolute bool) {
+	var urls []string
+	for _, ref := range scriptSrcs(src) {
+		if scriptUrlExpr.MatchString(ref) && !strings.Contains(ref, "/akam/") {
+			urls = append(urls, ref)
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+
+	sortScriptCandidates(urls)
+	lower := strings.ToLower(urls[0])
+	return true, urls[0], strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// sortScriptCandidates sorts the given script paths or URLs by descending scriptPathScore, keeping the
+// document order of equally scored candidates.
+func sortScriptCandidates(candidates []string) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scriptPathScore(candidates[i]) > scriptPathScore(candidates[j])
+	})
+}
+
+// scriptPathScore returns how Akamai-like the given script path or URL is: the number of its path
+// segments that look random. The scheme and host of absolute URLs are ignored.
+func scriptPathScore(ref string) (score int) {
+	if i := strings.Index(ref, "://"); i >= 0 {
+		ref = ref[i+len("://"):]
+		if j := strings.IndexByte(ref, '/'); j >= 0 {
+			ref = ref[j:]
+		} else {
+			ref = ""
+		}
+	}
+
+	for _, segment := range strings.Split(ref, "/") {
+		var upper, lower, digit bool
+		for _, c := range segment {
+			switch {
+			case 'A' <= c && c <= 'Z':
+				upper = true
+			case 'a' <= c && c <= 'z':
+				lower = true
+			case '0' <= c && c <= '9':
+				digit = true
+			}
+		}
+		if upper && lower || digit && (upper || lower) {
+			score++
+		}
 	}
 	return
 }
+
+// GetInlineScript gets the Akamai Bot Manager web SDK script inlined in a <script> tag of the given HTML
+// code src, rather than referenced by its src attribute. ok is true if the script was found, otherwise it
+// is false.
+//
+// The SDK is recognized by identifiers specific to it, such as the bmak object, rather than by the looser
+// signatures of DetectSdkVersion, which also match unrelated scripts like analytics snippets.
+func GetInlineScript(src []byte) (ok bool, script []byte) {
+	return findInlineScript(src, nil)
+}
+
+// getInlineScript is like GetInlineScript, but also recognizes scripts recognized by the session's
+// VersionDetectorFunc, if any (see WithVersionDetector).
+func (session Session) getInlineScript(src []byte) (ok bool, script []byte) {
+	return findInlineScript(src, session.versionDetector)
+}
+
+// findInlineScript implements GetInlineScript. Scripts recognized by detector are also returned, if it is
+// non-nil.
+func findInlineScript(src []byte, detector VersionDetectorFunc) (ok bool, script []byte) {
+	forEachScript(src, func(attrs map[string]string, body []byte) bool {
+		if _, hasSrc := attrs["src"]; hasSrc {
+			return true
+		}
+
+		body = bytes.TrimSpace(body)
+		recognized := inlineScriptExpr.Match(body)
+		if !recognized && detector != nil {
+			_, recognized = detector(body)
+		}
+		if recognized {
+			ok, script = true, body
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// scriptSrcs returns the src attribute values of the JavaScript <script> tags in the given HTML code src,
+// in document order. Tags without a src attribute are skipped.
+func scriptSrcs(src []byte) (srcs []string) {
+	forEachScript(src, func(attrs map[string]string, _ []byte) bool {
+		if ref := attrs["src"]; ref != "" {
+			srcs = append(srcs, ref)
+		}
+		return true
+	})
+	return
+}
+
+// forEachScript calls fn with the attributes and contents of the JavaScript <script> tags in the given HTML
+// code src, in document order, until fn returns false. The contents of a tag span up to the next closing
+// script tag, or the end of src if there is none.
+func forEachScript(src []byte, fn func(attrs map[string]string, body []byte) bool) {
+	for {
+		i := bytes.IndexByte(src, '<')
+		if i < 0 {
+			return
+		}
+		src = src[i+1:]
+		if len(src) < len("script") || !bytes.EqualFold(src[:len("script")], []byte("script")) {
+			continue
+		}
+		src = src[len("script"):]
+		// The tag name must end here, so that e.g. <scripts> is not mistaken for a script tag.
+		if len(src) > 0 && !isHTMLSpace(src[0]) && src[0] != '>' && src[0] != '/' {
+			continue
+		}
+
+		var attrs map[string]string
+		attrs, src = parseTagAttrs(src)
+		if typ, ok := attrs["type"]; ok && !isJavaScriptType(typ) {
+			continue
+		}
+
+		body := src
+		if end := indexFold(src, "</script"); end >= 0 {
+			body = src[:end]
+		}
+		if !fn(attrs, body) {
+			return
+		}
+	}
+}
+
+// indexFold is like bytes.Index, but matches the ASCII string sep case-insensitively.
+// The first byte of sep must not be a letter.
+func indexFold(s []byte, sep string) int {
+	for i := 0; ; i++ {
+		j := bytes.IndexByte(s[i:], sep[0])
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if i+len(sep) > len(s) {
+			return -1
+		}
+		if bytes.EqualFold(s[i:i+len(sep)], []byte(sep)) {
+			return i
+		}
+	}
+}
+
+// parseTagAttrs parses the attributes of a tag from src, which must start right after the tag name.
+// Attribute names are lowercased, values are unquoted and unescaped, and only the first occurrence of
+// an attribute is kept, like browsers do. rest is the remainder of src after the end of the tag.
+func parseTagAttrs(src []byte) (attrs map[string]string, rest []byte) {
+	attrs = make(map[string]string)
+	i := 0
+	for {
+		for i < len(src) && (isHTMLSpace(src[i]) || src[i] == '/') {
+			i++
+		}
+		if i >= len(src) {
+			return attrs, src[i:]
+		}
+		if src[i] == '>' {
+			return attrs, src[i+1:]
+		}
+
+		start := i
+		for i < len(src) && !isHTMLSpace(src[i]) && src[i] != '=' && src[i] != '>' && src[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(string(src[start:i]))
+
+		for i < len(src) && isHTMLSpace(src[i]) {
+			i++
+		}
+		var value string
+		if i < len(src) && src[i] == '=' {
+			i++
+			for i < len(src) && isHTMLSpace(src[i]) {
+				i++
+			}
+			if i < len(src) && (src[i] == '"' || src[i] == '\'') {
+				quote := src[i]
+				i++
+				start = i
+				for i < len(src) && src[i] != quote {
+					i++
+				}
+				value = string(src[start:i])
+				if i < len(src) {
+					i++
+				}
+			} else {
+				start = i
+				for i < len(src) && !isHTMLSpace(src[i]) && src[i] != '>' {
+					i++
+				}
+				value = string(src[start:i])
+			}
+		}
+
+		if _, ok := attrs[name]; !ok && name != "" {
+			attrs[name] = html.UnescapeString(value)
+		}
+	}
+}
+
+// isHTMLSpace reports if c is an ASCII whitespace character as defined by the HTML specification.
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// isJavaScriptType reports if the given <script> type attribute value denotes a classic JavaScript script.
+func isJavaScriptType(typ string) bool {
+	switch strings.ToLower(strings.TrimSpace(typ)) {
+	case "", "text/javascript", "application/javascript", "application/x-javascript", "text/ecmascript":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,119 @@
+package akamai
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGetScriptURL(t *testing.T) {
+	tests := []struct {
+		src      string
+		url      string
+		absolute bool
+	}{
+		{`<script type="text/javascript"  src="/aBc-dEf/gHi">`, "/aBc-dEf/gHi", false},
+		{`<script type="text/javascript"  src="https://cdn.example.com/aBc-dEf/gHi">`, "https://cdn.example.com/aBc-dEf/gHi", true},
+		{`<script type="text/javascript" src="https://www.example.com:8443/aBc-dEf/gHi">`, "https://www.example.com:8443/aBc-dEf/gHi", true},
+		{`<script async src='HTTPS://cdn.example.com/aBc-dEf/gHi'></script>`, "HTTPS://cdn.example.com/aBc-dEf/gHi", true},
+	}
+
+	for _, test := range tests {
+		ok, scriptUrl, absolute := GetScriptURL([]byte(test.src))
+		if !ok {
+			t.Fatal("ok == false on valid input:", test.src)
+		}
+		if scriptUrl != test.url || absolute != test.absolute {
+			t.Fatalf("unexpected result for %s: %s, %t", test.src, scriptUrl, absolute)
+		}
+	}
+
+	if ok, _, _ := GetScriptURL([]byte(`<script type="text/javascript" src="https://www.example.com/akam/13/1a2b3c">`)); ok {
+		t.Fatal("ok == true on pixel challenge script")
+	}
+}
+
+func TestGetScriptPath(t *testing.T) {
+	tests := []string{
+		`<script type="text/javascript"  src="/aBc-dEf/gHi">`,
+		`<script type='text/javascript' src='/aBc-dEf/gHi'></script>`,
+		`<script src="/aBc-dEf/gHi"></script>`,
+		`<script src="/aBc-dEf/gHi" type="text/javascript" async></script>`,
+		`<SCRIPT defer
+	src=/aBc-dEf/gHi TYPE="application/javascript"></SCRIPT>`,
+		`<script src="/other.js"></script><script nonce="abc" src="/aBc-dEf/gHi"></script>`,
+		`<script type="text/javascript" src="/akam/13/1a2b3c" defer></script><script src="/aBc-dEf/gHi"></script>`,
+	}
+	for _, src := range tests {
+		if ok, path := GetScriptPath([]byte(src)); !ok {
+			t.Fatal("ok == false on valid input:", src)
+		} else if path != "/aBc-dEf/gHi" {
+			t.Fatalf("unexpected path for %s: %s", src, path)
+		}
+	}
+
+	for _, src := range []string{
+		``,
+		`<script type="application/json" src="/aBc-dEf/gHi"></script>`,
+		`<scripts src="/aBc-dEf/gHi"></scripts>`,
+		`<script>var src = "/aBc-dEf/gHi";</script>`,
+		`<script src="/aBc-dEf/gHi.js"></script>`,
+	} {
+		if ok, _ := GetScriptPath([]byte(src)); ok {
+			t.Fatal("ok == true on invalid input:", src)
+		}
+	}
+}
+
+func TestGetScriptPathCandidates(t *testing.T) {
+	src, err := os.ReadFile("tests/multiple_scripts.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const sdkPath = "/Ho2tvp/sB7U/XuwD/AJ2g/Ax7iYv/iuYzG1EkYQp9/Cz1ZYW0/e2k/VNRNgBQ"
+	expected := []string{sdkPath, "/static/vendor", "/assets/js/main-bundle"}
+	if v := GetScriptPathCandidates(src); !reflect.DeepEqual(v, expected) {
+		t.Fatal("unexpected candidates:", v)
+	}
+	if ok, path := GetScriptPath(src); !ok || path != sdkPath {
+		t.Fatal("unexpected path:", path)
+	}
+	if ok, scriptUrl, absolute := GetScriptURL(src); !ok || scriptUrl != sdkPath || absolute {
+		t.Fatalf("unexpected script URL: %s, %t", scriptUrl, absolute)
+	}
+
+	if v := GetScriptPathCandidates([]byte(`<script src="/other.js"></script>`)); len(v) != 0 {
+		t.Fatal("unexpected candidates:", v)
+	}
+}
+
+func TestGetInlineScript(t *testing.T) {
+	src := `<html><head>
+<script src="/other.js"></script>
+<script type="application/json">{"a":1}</script>
+<script>window.foo = 1;</script>
+<SCRIPT type="text/javascript">
+var _acxj=[];(function(){})();
+</SCRIPT>
+</head></html>`
+	ok, script := GetInlineScript([]byte(src))
+	if !ok {
+		t.Fatal("ok == false on valid input")
+	}
+	if v := string(script); v != `var _acxj=[];(function(){})();` {
+		t.Fatal("unexpected script:", v)
+	}
+
+	if ok, _ := GetInlineScript([]byte(`<script>window.foo = 1;</script><script src="/aBc-dEf/gHi"></script>`)); ok {
+		t.Fatal("ok == true without inline script")
+	}
+	if ok, _ := GetInlineScript([]byte(`<script>(function(){window.dataLayer=[];})();</script>`)); ok {
+		t.Fatal("ok == true for unrelated inline script")
+	}
+	for _, script := range []string{`var _cf=_cf||[];`, `(function(){bmak.t=1;})();`, `(function(){s({"sensor_data":d});})();`} {
+		if ok, v := GetInlineScript([]byte(`<script>` + script + `</script>`)); !ok || string(v) != script {
+			t.Fatal("inline script not found:", script)
+		}
+	}
+}
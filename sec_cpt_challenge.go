@@ -0,0 +1,63 @@
+package akamai
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// secCptVerifyPath is the path sec_cpt challenge payloads are posted to.
+const secCptVerifyPath = "/_sec/cp_challenge/verify"
+
+var secCptChallengeExpr = regexp.MustCompile(`(?i)["'](/_sec/cp_challenge/[\w\-./]+)`)
+
+// GetSecCptChallenge gets the path of the sec_cpt challenge resource from the given HTML code src.
+// The sec_cpt challenge is an interstitial challenge served instead of (or alongside) the pixel challenge,
+// and is tracked with the `sec_cpt` cookie.
+//
+// ok is true if the challenge was found. The returned path always begins with /_sec/cp_challenge/. The path
+// challenge payloads are posted to, which pages may also reference, is not a challenge resource and is ignored.
+func GetSecCptChallenge(src []byte) (ok bool, challengePath string) {
+	for _, matches := range secCptChallengeExpr.FindAllSubmatch(src, -1) {
+		if path := string(matches[1]); !strings.HasPrefix(strings.ToLower(path), secCptVerifyPath) {
+			return true, path
+		}
+	}
+	return false, ""
+}
+
+// SecCptSolveRequest is the API sec_cpt challenge request schema.
+type SecCptSolveRequest struct {
+	UserAgent     string `json:"userAgent"`
+	PageURL       string `json:"pageUrl"`
+	ChallengePath string `json:"challengePath"`
+	SecCpt        string `json:"sec_cpt"`
+}
+
+// SecCptSolveResponse is the API sec_cpt challenge response schema.
+type SecCptSolveResponse struct {
+	Payload string `json:"payload"`
+}
+
+// GenerateSecCptPayload generates a payload to use to solve the sec_cpt challenge with the challenge
+// path obtained from GetSecCptChallenge and the current `sec_cpt` cookie. Support for the challenge is
+// experimental; see GenerateConfig.SolveSecCpt.
+//
+// Callers should send a POST request with the generated payload to /_sec/cp_challenge/verify on the
+// host of the page the challenge was found on, with the Content-Type HTTP request header set to
+// "application/json" (without quotes).
+//
+// Callers should prefer Generate over this method as Generate will handle generation
+// logic and processing automatically. This method is intended for callers who wish to interact with
+// the API directly.
+func (session Session) GenerateSecCptPayload(
+	ctx context.Context,
+	req *SecCptSolveRequest,
+) (*SecCptSolveResponse, error) {
+	var resp SecCptSolveResponse
+	if err := session.doAPIRequest(ctx, secCptEndpoint, req, &resp); err != nil {
+		return nil, err
+	}
+	session.observePayload(secCptEndpoint, resp.Payload)
+	return &resp, nil
+}
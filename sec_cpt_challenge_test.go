@@ -0,0 +1,52 @@
+package akamai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetSecCptChallenge(t *testing.T) {
+	const (
+		validInput   = `<script src="/_sec/cp_challenge/ak-challenge-4-3.js"></script>`
+		invalidInput = `<script src="/akam/13/1a2b3c"></script>`
+	)
+
+	if ok, path := GetSecCptChallenge([]byte(validInput)); !ok {
+		t.Fatal("ok == false on valid input")
+	} else if path != "/_sec/cp_challenge/ak-challenge-4-3.js" {
+		t.Fatal("unexpected path:", path)
+	}
+
+	if ok, _ := GetSecCptChallenge([]byte(invalidInput)); ok {
+		t.Fatal("ok == true on invalid input")
+	}
+
+	// The verify path is not a challenge resource
+	if ok, _ := GetSecCptChallenge([]byte(`fetch("/_sec/cp_challenge/verify", {method: "POST"})`)); ok {
+		t.Fatal("ok == true on verify path")
+	}
+	src := `fetch("/_sec/cp_challenge/verify");` + validInput
+	if ok, path := GetSecCptChallenge([]byte(src)); !ok || path != "/_sec/cp_challenge/ak-challenge-4-3.js" {
+		t.Fatal("unexpected result after verify path:", ok, path)
+	}
+}
+
+func TestGenerateSecCptOptIn(t *testing.T) {
+	session, _ := newTestSession(t)
+	browser := &testBrowser{
+		page:        `<html><script src="/_sec/cp_challenge/ak-challenge-4-3.js"></script></html>`,
+		abckCookies: []string{testValidAbck},
+	}
+	result, err := session.GenerateWithResult(context.Background(), testUserAgent, testPageURL, browser.doHttpReq, browser.getCookie, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.SecCptChallengePresent || result.SecCptSolved {
+		t.Fatalf("unexpected result: %+v", *result)
+	}
+	for _, op := range browser.ops {
+		if op == OpPostSecCpt {
+			t.Fatal("unexpected sec_cpt challenge payload POST request")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package akamai
+
+import (
+	"bytes"
+	"sync"
+)
+
+// SensorDataEnvelope returns the request body to POST the given sensor data payload with:
+// `{"sensor_data":"` + payload + `"}`.
+//
+// The payload is escaped the same way JavaScript's JSON.stringify escapes strings: double quotes and
+// backslashes are escaped with a backslash, control characters are escaped as `\b`, `\f`, `\n`, `\r`, `\t`
+// or `\u00XX`, and all other characters (including non-ASCII characters) are written as is. Payloads
+// generated by the SolarSystems API contain none of the escaped characters, in which case the envelope
+// is byte-for-byte the body Akamai Bot Manager itself sends, and the outer object is always valid JSON.
+func SensorDataEnvelope(payload string) []byte {
+	var buf bytes.Buffer
+	writeSensorDataEnvelope(&buf, payload)
+	return buf.Bytes()
+}
+
+// writeSensorDataEnvelope writes the envelope described by SensorDataEnvelope to buf.
+func writeSensorDataEnvelope(buf *bytes.Buffer, payload string) {
+	const hex = "0123456789abcdef"
+
+	buf.Grow(len(payload) + len(`{"sensor_data":""}`))
+	buf.WriteString(`{"sensor_data":"`)
+
+	start := 0
+	for i := 0; i < len(payload); i++ {
+		c := payload[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+
+		buf.WriteString(payload[start:i])
+		switch c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		}
+		start = i + 1
+	}
+	buf.WriteString(payload[start:])
+
+	buf.WriteString(`"}`)
+}
+
+// sensorDataBodyPool is a pool of *bytes.Buffer used to build sensor data POST request bodies.
+var sensorDataBodyPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// getSensorDataBody returns a buffer from sensorDataBodyPool containing the sensor data POST request body
+// for the given payload; see SensorDataEnvelope. Callers must return the buffer with putSensorDataBody
+// once it is no longer used.
+func getSensorDataBody(payload string) *bytes.Buffer {
+	buf := sensorDataBodyPool.Get().(*bytes.Buffer)
+	writeSensorDataEnvelope(buf, payload)
+	return buf
+}
+
+// putSensorDataBody resets buf and returns it to sensorDataBodyPool.
+func putSensorDataBody(buf *bytes.Buffer) {
+	buf.Reset()
+	sensorDataBodyPool.Put(buf)
+}
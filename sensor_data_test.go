@@ -0,0 +1,59 @@
+package akamai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+var benchmarkPayload = strings.Repeat("2;3556675;4277302;", 200)
+
+func TestGetSensorDataBody(t *testing.T) {
+	buf := getSensorDataBody("abc")
+	defer putSensorDataBody(buf)
+
+	if v := buf.String(); v != `{"sensor_data":"abc"}` {
+		t.Fatal("unexpected body:", v)
+	}
+}
+
+func TestSensorDataEnvelope(t *testing.T) {
+	if v := string(SensorDataEnvelope(benchmarkPayload)); v != `{"sensor_data":"`+benchmarkPayload+`"}` {
+		t.Fatal("unexpected envelope:", v)
+	}
+
+	payload := "a\"b\\c\nd\x01e\u00e9f"
+	envelope := SensorDataEnvelope(payload)
+	if v := string(envelope); v != `{"sensor_data":"a\"b\\c\nd\u0001eéf"}` {
+		t.Fatal("unexpected envelope:", v)
+	}
+
+	var decoded struct {
+		SensorData string `json:"sensor_data"`
+	}
+	if err := json.Unmarshal(envelope, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SensorData != payload {
+		t.Fatalf("unexpected decoded payload: %q", decoded.SensorData)
+	}
+}
+
+func BenchmarkSensorDataBodySprintf(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = io.Copy(io.Discard, bytes.NewBufferString(fmt.Sprintf(`{"sensor_data":"%s"}`, benchmarkPayload)))
+	}
+}
+
+func BenchmarkSensorDataBodyPool(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := getSensorDataBody(benchmarkPayload)
+		_, _ = io.Copy(io.Discard, buf)
+		putSensorDataBody(buf)
+	}
+}
@@ -0,0 +1,44 @@
+package akamai
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/SolarSystems-Software/akamai-sdk-go/internal"
+)
+
+// jsStringExpr matches JavaScript string literals without line breaks.
+var jsStringExpr = regexp.MustCompile(`"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*'`)
+
+// minSensorEndpointScore is the minimum scriptPathScore of a path embedded in the web SDK script for it to
+// be recognized as the sensor data endpoint.
+const minSensorEndpointScore = 2
+
+// GetSensorEndpoint gets the path the Akamai Bot Manager web SDK script posts sensor data to from the
+// given JavaScript code scriptBody, for scripts embedding an endpoint that differs from their own path.
+// ok is true if an endpoint was found, otherwise it is false. Generation only uses the endpoint if
+// GenerateConfig.DetectSensorEndpoint is set.
+//
+// The endpoint is recognized among the string literals of the script, which may use hexadecimal escape
+// sequences, as a path with the shape of a web SDK path (see GetScriptPathCandidates) with at least two
+// random-looking segments. If the script embeds several, the most Akamai-like one is returned.
+func GetSensorEndpoint(scriptBody []byte) (path string, ok bool) {
+	bestScore := minSensorEndpointScore - 1
+	for _, literal := range jsStringExpr.FindAll(scriptBody, -1) {
+		value := string(literal[1 : len(literal)-1])
+		if strings.IndexByte(value, '\\') >= 0 {
+			var err error
+			if value, err = internal.FromHexString(value); err != nil {
+				continue
+			}
+		}
+		if !strings.HasPrefix(value, "/") || !scriptPathExpr.MatchString(value) || strings.Contains(value, "/akam/") {
+			continue
+		}
+
+		if score := scriptPathScore(value); score > bestScore {
+			path, ok, bestScore = value, true, score
+		}
+	}
+	return
+}
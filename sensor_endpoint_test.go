@@ -0,0 +1,34 @@
+package akamai
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGetSensorEndpoint(t *testing.T) {
+	script, err := os.ReadFile("tests/sdk_endpoint.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, ok := GetSensorEndpoint(script); !ok || path != "/Ht4xQ/pB7uK/XuwD2/telemetry" {
+		t.Fatalf("unexpected result: %s, %t", path, ok)
+	}
+
+	if path, ok := GetSensorEndpoint([]byte(`var a='/aBc-dEf/gHi',b="/static/app";`)); !ok || path != "/aBc-dEf/gHi" {
+		t.Fatalf("unexpected result: %s, %t", path, ok)
+	}
+
+	script, err = os.ReadFile("tests/sdk_175.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range [][]byte{
+		script,
+		[]byte(testSdkScript),
+		[]byte(`var a="/static/app",b="/akam/13/1a2b3c",c="/v2",d="/aBc/gHi.js";`),
+	} {
+		if path, ok := GetSensorEndpoint(src); ok {
+			t.Fatal("unexpected endpoint:", path)
+		}
+	}
+}
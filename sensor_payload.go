@@ -0,0 +1,43 @@
+package akamai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSensorPayload is an error caused by ValidateSensorPayload if a sensor data payload is
+// obviously malformed.
+var ErrInvalidSensorPayload = errors.New("akamai-sdk-go: invalid sensor data payload")
+
+// minSensorPayloadLength is the minimum length of a sensor data payload. Payloads are several kilobytes
+// long, as they encode the fingerprint of a browser and the events of a page visit.
+const minSensorPayloadLength = 100
+
+// sensorPayloadSeparators are the separators that sensor data payloads contain for each web SDK version.
+var sensorPayloadSeparators = map[Version]string{
+	Version17:  "-1,2,-94,",
+	Version175: "-1,2,-94,",
+	Version2:   ";",
+}
+
+// ValidateSensorPayload checks a sensor data payload generated for the given web SDK version with
+// lightweight heuristics, to catch obviously malformed payloads returned on transient API failures before
+// wasting a POST request on them. The payload must be at least 100 bytes long, must not contain control
+// characters, and must contain the field separators of the version, if it is known. Passing validation does
+// not mean the payload will be accepted by Akamai Bot Manager.
+//
+// The error returned is non-nil if the payload is invalid, in which case it is ErrInvalidSensorPayload
+// joined with the reason. See GenerateConfig.SensorPayloadRetries.
+func ValidateSensorPayload(version Version, payload string) error {
+	if len(payload) < minSensorPayloadLength {
+		return errors.Join(ErrInvalidSensorPayload, fmt.Errorf("payload too short: %d bytes", len(payload)))
+	}
+	if i := strings.IndexFunc(payload, func(r rune) bool { return r < 0x20 || r == 0x7f }); i >= 0 {
+		return errors.Join(ErrInvalidSensorPayload, fmt.Errorf("control character at byte %d", i))
+	}
+	if separator, ok := sensorPayloadSeparators[version]; ok && !strings.Contains(payload, separator) {
+		return errors.Join(ErrInvalidSensorPayload, fmt.Errorf("no %q separator for version %s", separator, version))
+	}
+	return nil
+}
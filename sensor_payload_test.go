@@ -0,0 +1,91 @@
+package akamai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// testSensorPayload175 is a well-formed version 1.75 sensor data payload.
+var testSensorPayload175 = strings.Repeat("-1,2,-94,-100,0,0,0,0,1,0,0,", 4)
+
+func TestValidateSensorPayload(t *testing.T) {
+	valid := map[Version]string{
+		Version17:  testSensorPayload175,
+		Version175: testSensorPayload175,
+		Version2:   strings.Repeat("3;0;1;0;", 20),
+		"9.9":      strings.Repeat("a", 100),
+	}
+	for version, payload := range valid {
+		if err := ValidateSensorPayload(version, payload); err != nil {
+			t.Fatalf("err != nil on valid payload for version %s: %v", version, err)
+		}
+	}
+
+	invalid := map[Version]string{
+		Version175: strings.Repeat("a", 200),
+		Version2:   strings.Repeat("a", 200),
+		Version17:  "-1,2,-94,",
+		"9.9":      strings.Repeat("a", 99) + "\n",
+	}
+	for version, payload := range invalid {
+		if err := ValidateSensorPayload(version, payload); !errors.Is(err, ErrInvalidSensorPayload) {
+			t.Fatalf("expected ErrInvalidSensorPayload for version %s, got: %v", version, err)
+		}
+	}
+}
+
+func TestGenerateSensorPayloadRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != sensorEndpoint.path {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		payload := "truncated"
+		if calls.Add(1) > 2 {
+			payload = testSensorPayload175
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(GenerateResponse{Payload: payload})
+	}))
+	defer server.Close()
+
+	session := NewSessionWithOptions("", WithBaseURL(server.URL))
+	page := `<html><script src="/aBc-dEf/gHi"></script></html>`
+	cfg := DefaultGenerateConfig()
+	cfg.SensorMaxTries = 1
+	cfg.SensorPayloadRetries = 2
+	browser := &testBrowser{page: page}
+	result, err := session.GenerateWithConfig(context.Background(), testUserAgent, testPageURL, browser.doHttpReq, browser.getCookie, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := calls.Load(); v != 3 {
+		t.Fatal("expected 3 sensor API calls, got:", v)
+	}
+	if result.SensorPostCount != 1 || result.SensorPayloadBytes[0] != len(testSensorPayload175) {
+		t.Fatalf("unexpected result: %+v", *result)
+	}
+
+	// Giving up without posting
+	calls.Store(0)
+	cfg.SensorPayloadRetries = 1
+	browser = &testBrowser{page: page}
+	if _, err = session.GenerateWithConfig(context.Background(), testUserAgent, testPageURL, browser.doHttpReq, browser.getCookie, cfg); !errors.Is(err, ErrInvalidSensorPayload) {
+		t.Fatal("expected ErrInvalidSensorPayload, got:", err)
+	}
+	if v := calls.Load(); v != 2 {
+		t.Fatal("expected 2 sensor API calls, got:", v)
+	}
+	for _, op := range browser.ops {
+		if op == OpPostSensorData {
+			t.Fatal("unexpected sensor data POST request")
+		}
+	}
+}
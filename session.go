@@ -2,8 +2,15 @@ package akamai
 
 import (
 	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// defaultBaseURL is the base URL of the public SolarSystems API.
+const defaultBaseURL = "https://akamai.publicapis.solarsystems.software"
+
 // Session is an API session that allows interaction with the SolarSystems Akamai API.
 // Sessions should be created with one of the utility functions, like NewSession.
 //
@@ -15,6 +22,111 @@ type Session struct {
 
 	// The http.Client to use when making API requests.
 	client *http.Client
+
+	// The base URL of the SolarSystems API without a trailing slash.
+	// If empty, defaultBaseURL is used.
+	baseURL string
+
+	// The policy used to retry failed API requests. If nil, requests are not retried.
+	retry *RetryPolicy
+
+	// Whether Generate refreshes a missing or malformed bm_sz cookie for scripts requiring it (see RequiresBmSz).
+	// See WithBmSzRefresh.
+	refreshBmSz bool
+
+	// The observer notified of request timings. It may be nil.
+	observer Observer
+
+	// Whether user agent validation is disabled. See WithoutUserAgentValidation.
+	skipUserAgentValidation bool
+
+	// The user agent used by Generate if none is given. See WithDefaultUserAgent.
+	userAgent string
+
+	// The logger to log debug messages with. It may be nil.
+	logger *lockedLogger
+
+	// The semaphore limiting concurrent API requests. If nil, there is no limit.
+	apiSlots chan struct{}
+
+	// Additional headers to send with API requests. See WithAPIHeaders.
+	apiHeaders http.Header
+
+	// The number of successful API requests. See APICallCount.
+	apiCalls *atomic.Uint64
+
+	// The clock to read the current time from. If nil, the system clock is used. See WithClock.
+	clock Clock
+
+	// The timeout of each API request attempt. If zero, there is none. See WithAPICallTimeout.
+	apiCallTimeout time.Duration
+
+	// The function detecting the web SDK version. If nil, DetectSdkVersion is used. See WithVersionDetector.
+	versionDetector VersionDetectorFunc
+
+	// The HTTP request headers used by GenerateWithJar for each operation. See WithRequestHeaders.
+	requestHeaders map[HttpReqOp]http.Header
+
+	// The circuit breaker of API requests. If nil, there is none. See WithCircuitBreaker.
+	breaker *circuitBreaker
+
+	// The tracer to create spans with. If nil, no spans are created. See WithTracer.
+	tracer Tracer
+
+	// The lower-case hosts payloads may be posted to. If nil, all hosts are allowed. See WithAllowedTargetHosts.
+	allowedHosts []string
+
+	// Additional patterns of pixel challenge script URLs. See WithPixelURLPatterns.
+	pixelPatterns []*regexp.Regexp
+}
+
+// SessionOption configures a Session created with NewSessionWithOptions.
+type SessionOption func(session *Session)
+
+// WithBaseURL sets the base URL (scheme and host, optionally with a path prefix) of the SolarSystems API.
+// This is useful for callers routed to a regional endpoint or running the API behind a proxy with a
+// different hostname. A trailing slash is trimmed.
+//
+// If baseURL is empty, the public SolarSystems API is used.
+func WithBaseURL(baseURL string) SessionOption {
+	return func(session *Session) {
+		session.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to make requests to the SolarSystems API, like
+// NewSessionWithClient. Sessions created with NewSessionWithOptions use http.DefaultClient otherwise.
+//
+// WithHTTPClient panics if client == nil.
+func WithHTTPClient(client *http.Client) SessionOption {
+	if client == nil {
+		panic("akamai-sdk-go: nil client passed to WithHTTPClient")
+	}
+
+	return func(session *Session) {
+		session.client = client
+	}
+}
+
+// WithRoundTripper sets the transport used to make requests to the SolarSystems API, e.g. to wrap the
+// transport of the session's client with logging or metrics middleware. It replaces the transport only, not
+// the client: the session gets its own copy of its client with the given transport, keeping its other
+// settings like the timeout of a client set with an earlier WithHTTPClient option. The client passed to
+// WithHTTPClient and http.DefaultClient are never modified.
+// The x-api-key header and the headers set with WithAPIHeaders are set on each request, so they are seen by
+// the transport.
+//
+// WithRoundTripper panics if transport == nil.
+func WithRoundTripper(transport http.RoundTripper) SessionOption {
+	if transport == nil {
+		panic("akamai-sdk-go: nil transport passed to WithRoundTripper")
+	}
+
+	return func(session *Session) {
+		client := *session.client
+		client.Transport = transport
+		session.client = &client
+	}
 }
 
 // NewSessionWithClient creates a new Session with the given API key and HTTP client.
@@ -27,8 +139,9 @@ func NewSessionWithClient(apiKey string, client *http.Client) Session {
 	}
 
 	return Session{
-		apiKey: apiKey,
-		client: client,
+		apiKey:   apiKey,
+		client:   client,
+		apiCalls: new(atomic.Uint64),
 	}
 }
 
@@ -37,3 +150,71 @@ func NewSessionWithClient(apiKey string, client *http.Client) Session {
 func NewSession(apiKey string) Session {
 	return NewSessionWithClient(apiKey, http.DefaultClient)
 }
+
+// NewSessionWithTimeout creates a new Session with the given API key and a dedicated HTTP client with the
+// given timeout for each API request.
+//
+// Callers should prefer NewSessionWithTimeout over setting the Timeout of http.DefaultClient, which is
+// shared with all other code in the program using http.DefaultClient.
+func NewSessionWithTimeout(apiKey string, timeout time.Duration) Session {
+	return NewSessionWithClient(apiKey, &http.Client{Timeout: timeout})
+}
+
+// Transport settings used by NewTunedSession.
+const (
+	tunedMaxIdleConns        = 256
+	tunedMaxIdleConnsPerHost = 64
+	tunedIdleConnTimeout     = 90 * time.Second
+)
+
+// NewTunedSession creates a new Session with the given API key and a dedicated HTTP client whose transport
+// is tuned for making many concurrent API requests. Compared to http.DefaultTransport, which keeps at most
+// two idle connections per host, the transport keeps up to 64 idle connections to the SolarSystems API
+// (256 in total) for 90 seconds and attempts HTTP/2. This avoids opening a new connection for most API
+// requests when generating at scale.
+func NewTunedSession(apiKey string) Session {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = tunedMaxIdleConns
+	transport.MaxIdleConnsPerHost = tunedMaxIdleConnsPerHost
+	transport.IdleConnTimeout = tunedIdleConnTimeout
+	transport.ForceAttemptHTTP2 = true
+
+	return NewSessionWithClient(apiKey, &http.Client{Transport: transport})
+}
+
+// NewSessionWithOptions creates a new Session with the given API key, configured with the given options.
+// It uses the default client to make requests to the SolarSystems API, unless one is set with WithHTTPClient.
+func NewSessionWithOptions(apiKey string, options ...SessionOption) Session {
+	session := NewSession(apiKey)
+	for _, option := range options {
+		option(&session)
+	}
+	return session
+}
+
+// apiURL returns the absolute URL of the SolarSystems API endpoint with the given path.
+func (session Session) apiURL(path string) string {
+	baseURL := session.baseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return baseURL + path
+}
+
+// Close closes the idle connections of the session's HTTP client. Calling Close is optional; it is useful
+// for long-running programs creating short-lived sessions with their own client, to avoid leaking
+// file descriptors. Close is idempotent and always returns nil.
+//
+// Close does nothing if the session uses http.DefaultClient or a client using http.DefaultTransport,
+// as closing the idle connections of a shared transport would affect unrelated code. Sessions shared
+// between goroutines should not be closed while they are in use.
+func (session Session) Close() error {
+	client := session.client
+	if client == nil || client == http.DefaultClient || client.Transport == nil ||
+		client.Transport == http.DefaultTransport {
+		return nil
+	}
+
+	client.CloseIdleConnections()
+	return nil
+}
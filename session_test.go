@@ -0,0 +1,115 @@
+package akamai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionApiURL(t *testing.T) {
+	if v := NewSession("").apiURL("/v1/sensor/generate"); v != defaultBaseURL+"/v1/sensor/generate" {
+		t.Fatal("unexpected default API URL:", v)
+	}
+
+	session := NewSessionWithOptions("", WithBaseURL("https://eu.example.com/akamai/"))
+	if v := session.apiURL("/v1/pixel/generate"); v != "https://eu.example.com/akamai/v1/pixel/generate" {
+		t.Fatal("unexpected API URL:", v)
+	}
+}
+
+func TestSessionClose(t *testing.T) {
+	for _, session := range []Session{
+		NewSession(""),
+		NewSessionWithClient("", &http.Client{Transport: &http.Transport{}}),
+	} {
+		if err := session.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNewSessionWithTimeout(t *testing.T) {
+	session := NewSessionWithTimeout("", time.Second)
+	if session.client == http.DefaultClient || session.client.Timeout != time.Second {
+		t.Fatal("unexpected client:", session.client)
+	}
+	if http.DefaultClient.Timeout != 0 {
+		t.Fatal("http.DefaultClient was modified")
+	}
+}
+
+// roundTripperFunc is an http.RoundTripper implemented by a function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestWithRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	defer server.Close()
+
+	var apiKeys []string
+	middleware := roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		apiKeys = append(apiKeys, request.Header.Get("x-api-key"))
+		return http.DefaultTransport.RoundTrip(request)
+	})
+	session := NewSessionWithOptions("key", WithBaseURL(server.URL), WithRoundTripper(middleware))
+	if _, err := session.GenerateSensorData(context.Background(), testGenerateRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if len(apiKeys) != 1 || apiKeys[0] != "key" {
+		t.Fatal("unexpected API keys seen by the transport:", apiKeys)
+	}
+	if http.DefaultClient.Transport != nil {
+		t.Fatal("http.DefaultClient was modified")
+	}
+
+	client := &http.Client{Timeout: time.Second}
+	session = NewSessionWithOptions("key", WithHTTPClient(client), WithRoundTripper(middleware))
+	if session.client == client || session.client.Timeout != time.Second || client.Transport != nil {
+		t.Fatal("unexpected client:", session.client)
+	}
+}
+
+func benchmarkSessionTransport(b *testing.B, newSession func() Session) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"payload":"payload"}`))
+	}))
+	defer server.Close()
+
+	session := newSession()
+	session.baseURL = server.URL
+	defer session.Close()
+
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := session.GenerateSensorData(context.Background(), testGenerateRequest()); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSessionDefaultTransport(b *testing.B) {
+	benchmarkSessionTransport(b, func() Session {
+		return NewSessionWithClient("", &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()})
+	})
+}
+
+func BenchmarkSessionTunedTransport(b *testing.B) {
+	benchmarkSessionTransport(b, func() Session {
+		return NewTunedSession("")
+	})
+}
@@ -1,6 +1,7 @@
 package akamai
 
 import (
+	"net/url"
 	"strconv"
 	"strings"
 )
@@ -17,14 +18,98 @@ import (
 // to a protected endpoint. Sensor data obtained from the SolarSystems API typically requires one POST
 // request to obtain a valid cookie, or two if the application uses challenges.
 func IsCookieValid(value string, requestCount int) bool {
-	parts := strings.Split(value, "~")
-	if len(parts) < 2 {
+	switch ParseAbckState(value) {
+	case AbckValid, AbckValidPendingMorePosts:
+		requestThreshold, _ := ParseStopSignal(value)
+		return requestCount >= requestThreshold
+	default:
 		return false
 	}
+}
+
+// AbckState is the state of an `_abck` cookie; see ParseAbckState.
+type AbckState byte
+
+func (state AbckState) String() string {
+	switch state {
+	case AbckUnset:
+		return "AbckUnset"
+	case AbckInvalid:
+		return "AbckInvalid"
+	case AbckValidPendingMorePosts:
+		return "AbckValidPendingMorePosts"
+	case AbckValid:
+		return "AbckValid"
+	default:
+		return ""
+	}
+}
+
+const (
+	// AbckUnset is the state of a missing `_abck` cookie.
+	AbckUnset AbckState = iota
 
-	requestThreshold, err := strconv.Atoi(parts[1])
-	if err != nil {
-		requestThreshold = -1
+	// AbckInvalid is the state of an `_abck` cookie that is malformed, or whose stop signal field is -1.
+	// Websites without the stop signal enabled also use -1 for valid cookies; see IsCookieValid.
+	AbckInvalid
+
+	// AbckValidPendingMorePosts is the state of an `_abck` cookie whose stop signal threshold is positive,
+	// meaning it is only valid once that many sensor data POST requests were sent (starting at zero).
+	AbckValidPendingMorePosts
+
+	// AbckValid is the state of an `_abck` cookie whose stop signal threshold is zero.
+	AbckValid
+)
+
+// ParseAbckState parses the state of the given `_abck` cookie value, which is normalized with NormalizeAbck.
+//
+// An `_abck` cookie consists of `~`-delimited fields: a hexadecimal hash, the stop signal threshold (see
+// ParseStopSignal), the base64-encoded cookie data, and three trailing fields which are usually -1. Only
+// the stop signal field is used to classify the cookie.
+func ParseAbckState(value string) AbckState {
+	if value == "" {
+		return AbckUnset
+	}
+
+	threshold, ok := ParseStopSignal(value)
+	switch {
+	case !ok || threshold < 0:
+		return AbckInvalid
+	case threshold > 0:
+		return AbckValidPendingMorePosts
+	default:
+		return AbckValid
+	}
+}
+
+// ParseStopSignal parses the stop signal request threshold from the given `_abck` cookie value.
+// The threshold is the second `~`-delimited field of the cookie. It is the number of requests (starting at zero)
+// after which the client should stop posting sensor data; see IsCookieValid for more information.
+//
+// ok is false if the field is missing, is not a number, or is -1 (the stop signal is not enabled or the cookie
+// is not valid yet). The value is normalized with NormalizeAbck first.
+func ParseStopSignal(value string) (threshold int, ok bool) {
+	parts := strings.Split(NormalizeAbck(value), "~")
+	if len(parts) < 2 {
+		return 0, false
+	}
+
+	threshold, err := strconv.Atoi(parts[1])
+	if err != nil || threshold == -1 {
+		return 0, false
+	}
+	return threshold, true
+}
+
+// NormalizeAbck decodes an `_abck` cookie value returned URL-encoded by some cookie jars, e.g. with `%7E`
+// instead of `~`. Values that are not URL-encoded are returned unchanged, so normalizing a value twice
+// has no effect. Unlike query decoding, `+` is preserved, as it is part of the cookie's base64 field.
+func NormalizeAbck(value string) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+	if decoded, err := url.PathUnescape(value); err == nil {
+		return decoded
 	}
-	return requestThreshold != -1 && requestCount >= requestThreshold
+	return value
 }
@@ -1,6 +1,9 @@
 package akamai
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestIsCookieValid(t *testing.T) {
 	const (
@@ -16,4 +19,71 @@ func TestIsCookieValid(t *testing.T) {
 	if IsCookieValid(invalidCookie, 1) {
 		t.Fail()
 	}
+
+	if !IsCookieValid(strings.ReplaceAll(validCookie, "~", "%7E"), 1) {
+		t.Fail()
+	}
+
+	if IsCookieValid(strings.ReplaceAll(invalidCookie, "~", "%7e"), 1) {
+		t.Fail()
+	}
+}
+
+func TestNormalizeAbck(t *testing.T) {
+	tests := map[string]string{
+		"":                                "",
+		"0C8A~0~YAAQ+a/b=~-1":             "0C8A~0~YAAQ+a/b=~-1",
+		"0C8A%7E0%7EYAAQ+a/b=~-1":         "0C8A~0~YAAQ+a/b=~-1",
+		"0C8A%7E0%7EYAAQ%2Ba%2Fb%3D%7E-1": "0C8A~0~YAAQ+a/b=~-1",
+		"0C8A~0~YAAQ%zz":                  "0C8A~0~YAAQ%zz",
+	}
+	for value, expected := range tests {
+		normalized := NormalizeAbck(value)
+		if normalized != expected {
+			t.Fatalf("unexpected value for %s: %s", value, normalized)
+		}
+		if v := NormalizeAbck(normalized); v != normalized {
+			t.Fatalf("normalizing %s is not idempotent: %s", value, v)
+		}
+	}
+}
+
+func TestParseStopSignal(t *testing.T) {
+	tests := []struct {
+		value     string
+		threshold int
+		ok        bool
+	}{
+		{"", 0, false},
+		{"0C8A2251CC04F60F59160D6AD92DA8A0", 0, false},
+		{"0C8A2251CC04F60F59160D6AD92DA8A0~-1~YAAQ~-1~-1~-1", 0, false},
+		{"0C8A2251CC04F60F59160D6AD92DA8A0~abc~YAAQ~-1~-1~-1", 0, false},
+		{"0C8A2251CC04F60F59160D6AD92DA8A0~0~YAAQ~-1~-1~-1", 0, true},
+		{"0C8A2251CC04F60F59160D6AD92DA8A0~2~YAAQ~-1~-1~-1", 2, true},
+	}
+
+	for _, test := range tests {
+		threshold, ok := ParseStopSignal(test.value)
+		if threshold != test.threshold || ok != test.ok {
+			t.Fatalf("unexpected result for %s: %d, %t", test.value, threshold, ok)
+		}
+	}
+}
+
+func TestParseAbckState(t *testing.T) {
+	tests := map[string]AbckState{
+		"":                                 AbckUnset,
+		"0C8A2251CC04F60F59160D6AD92DA8A0": AbckInvalid,
+		"0C8A2251CC04F60F59160D6AD92DA8A0~abc~YAAQ~-1~-1~-1":                         AbckInvalid,
+		"854B24C98DF862FDB9DCD7A8D317E790~-1~YAAQD9EuF64U3i+GAQAA~-1~-1~-1":          AbckInvalid,
+		"854B24C98DF862FDB9DCD7A8D317E790~-1~YAAQD9EuF64U3i+GAQAA~0~-1~-1":           AbckInvalid,
+		"0C8A2251CC04F60F59160D6AD92DA8A0~2~YAAQlivJF6o1GjGGAQAA~-1~-1~-1":           AbckValidPendingMorePosts,
+		"0C8A2251CC04F60F59160D6AD92DA8A0~0~YAAQlivJF6o1GjGGAQAA~-1~-1~-1":           AbckValid,
+		"0C8A2251CC04F60F59160D6AD92DA8A0%7E0%7EYAAQlivJF6o1GjGGAQAA%7E-1%7E-1%7E-1": AbckValid,
+	}
+	for value, expected := range tests {
+		if state := ParseAbckState(value); state != expected {
+			t.Fatalf("unexpected state for %s: %s", value, state)
+		}
+	}
 }
@@ -0,0 +1,62 @@
+package akamai
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrDisallowedHost is an error caused by Session.Generate and its variants if a payload would be posted to
+// a host that is not allowed by WithAllowedTargetHosts.
+var ErrDisallowedHost = errors.New("akamai-sdk-go: disallowed target host")
+
+// WithAllowedTargetHosts restricts the hosts Session.Generate and its variants post sensor data and challenge
+// payloads to (OpPostSensorData, OpPostPixelPayload and OpPostSecCpt). Hosts are matched case-insensitively
+// against the host name of the request URL, without port; a host starting with "*." also matches all of its
+// subdomains, but not the domain itself. Requests to other hosts fail with ErrDisallowedHost before the
+// payload is generated, so no API request is made for them either.
+//
+// The URLs posted to are parsed from the page and the web SDK script (see GetScriptURL, GetSensorEndpoint
+// and GetPixelChallengeScriptURL), so they are controlled by whoever serves the page. A compromised or
+// malicious page could otherwise make the caller post payloads, and the cookies its DoHttpReqFunc attaches
+// to them, to a host of its choice. The allowed hosts typically are the host of the page and its Akamai
+// endpoints. GET requests, and the page URL passed to Generate, are not restricted, and the requests made
+// to the SolarSystems API always go to the base URL of the session.
+//
+// WithAllowedTargetHosts panics if hosts is empty or contains an empty host.
+func WithAllowedTargetHosts(hosts []string) SessionOption {
+	if len(hosts) == 0 {
+		panic("akamai-sdk-go: no hosts passed to WithAllowedTargetHosts")
+	}
+	allowed := make([]string, len(hosts))
+	for i, host := range hosts {
+		if host == "" || host == "*." {
+			panic("akamai-sdk-go: empty host passed to WithAllowedTargetHosts")
+		}
+		allowed[i] = strings.ToLower(host)
+	}
+
+	return func(session *Session) {
+		session.allowedHosts = allowed
+	}
+}
+
+// checkTargetHost returns an error if the session does not allow op to post to requestUrl.
+func (session Session) checkTargetHost(op HttpReqOp, requestUrl string) error {
+	if session.allowedHosts == nil {
+		return nil
+	}
+
+	u, err := url.Parse(requestUrl)
+	if err != nil {
+		return errors.Join(HttpOpError{Op: op}, err)
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range session.allowedHosts {
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return nil
+		}
+	}
+	return errors.Join(HttpOpError{Op: op}, ErrDisallowedHost, fmt.Errorf("host: %s", u.Host))
+}
@@ -0,0 +1,63 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithAllowedTargetHosts(t *testing.T) {
+	session, api := newTestSession(t, WithAllowedTargetHosts([]string{"WWW.example.com", "*.akamai.example.com"}))
+	browser := &testBrowser{abckCookies: []string{testValidAbck}}
+	if err := session.Generate(context.Background(), testUserAgent, testPageURL, browser.doHttpReq, browser.getCookie, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// A page pointing the sensor data and pixel challenge payload POST requests to another host
+	page := strings.NewReplacer(
+		`src="/aBc-dEf/gHi"`, `src="https://attacker.example.net/aBc-dEf/gHi"`,
+		"https://www.example.com/akam/", "https://attacker.example.net/akam/",
+	).Replace(testPageBody)
+	session, api = newTestSession(t, WithAllowedTargetHosts([]string{"www.example.com", "*.akamai.example.com"}))
+	browser = &testBrowser{page: page}
+	err := session.Generate(context.Background(), testUserAgent, testPageURL, browser.doHttpReq, browser.getCookie, 1)
+	if !errors.Is(err, ErrDisallowedHost) {
+		t.Fatal("expected ErrDisallowedHost, got:", err)
+	}
+	if !strings.Contains(err.Error(), "attacker.example.net") {
+		t.Fatal("expected disallowed host in error, got:", err)
+	}
+	for _, op := range browser.ops {
+		if op == OpPostSensorData || op == OpPostPixelPayload {
+			t.Fatal("unexpected request:", op)
+		}
+	}
+	if api.sensorCalls.Load() != 0 || api.pixelCalls.Load() != 0 {
+		t.Fatal("expected no API calls")
+	}
+
+	// Subdomains match wildcard hosts
+	for _, test := range []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://www.example.com/aBc-dEf/gHi", true},
+		{"https://www.example.com:8443/aBc-dEf/gHi", true},
+		{"https://cdn.akamai.example.com/aBc-dEf/gHi", true},
+		{"https://akamai.example.com/aBc-dEf/gHi", false},
+		{"https://evilakamai.example.com/aBc-dEf/gHi", false},
+		{"https://example.com/aBc-dEf/gHi", false},
+	} {
+		if err := session.checkTargetHost(OpPostSensorData, test.url); (err == nil) != test.allowed {
+			t.Fatalf("unexpected result for %s: %v", test.url, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on empty hosts")
+		}
+	}()
+	WithAllowedTargetHosts(nil)
+}
@@ -0,0 +1,2 @@
+var _acxj=["\x74\x68\x65\x6e","\x2f\x61\x73\x73\x65\x74\x73\x2f\x61\x70\x70","\x2f\x48\x74\x34\x78\x51\x2f\x70\x42\x37\x75\x4b\x2f\x58\x75\x77\x44\x32\x2f\x74\x65\x6c\x65\x6d\x65\x74\x72\x79"];
+(function(){var e=_acxj[2];bmak.sensor_endpoint=e;})();
@@ -0,0 +1,44 @@
+package akamai
+
+import "context"
+
+// Tracer creates tracing spans around the phases of Session.Generate, which allows callers to adapt the
+// SDK to a tracing backend like OpenTelemetry. Implementations must be safe for usage by multiple
+// goroutines, as Session.Generate starts spans from multiple goroutines.
+type Tracer interface {
+	// StartSpan starts a span with the given name as a child of the span in ctx, if any. It returns a
+	// context carrying the new span, which is passed to the requests made during the span, and a function
+	// ending the span with the error of the phase, which is nil on success.
+	StartSpan(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+// Span names used by Session.Generate.
+const (
+	// SpanGetPage is the name of the span around the page GET request.
+	SpanGetPage = "akamai.get_page"
+
+	// SpanGetSdkScript is the name of the span around the web SDK script GET request.
+	SpanGetSdkScript = "akamai.get_sdk_script"
+
+	// SpanPostSensorData is the name of the span around each sensor data attempt, which generates the
+	// sensor data with the SolarSystems API and posts it.
+	SpanPostSensorData = "akamai.post_sensor_data"
+
+	// SpanSolvePixelChallenge is the name of the span around solving each pixel challenge.
+	SpanSolvePixelChallenge = "akamai.solve_pixel_challenge"
+)
+
+// WithTracer sets the Tracer of the session. If tracer is nil, no spans are created, which is the default.
+func WithTracer(tracer Tracer) SessionOption {
+	return func(session *Session) {
+		session.tracer = tracer
+	}
+}
+
+// startSpan starts a span with the session's Tracer, if any. See Tracer.StartSpan.
+func (session Session) startSpan(ctx context.Context, name string) (context.Context, func(err error)) {
+	if session.tracer == nil {
+		return ctx, func(error) {}
+	}
+	return session.tracer.StartSpan(ctx, name)
+}
@@ -0,0 +1,73 @@
+package akamai
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+)
+
+// spanKey is the context key of the span name set by testTracer.
+type spanKey struct{}
+
+// testTracer is a Tracer recording the names of the ended spans.
+type testTracer struct {
+	mu    sync.Mutex
+	spans map[string]int
+}
+
+func (tr *testTracer) StartSpan(ctx context.Context, name string) (context.Context, func(err error)) {
+	return context.WithValue(ctx, spanKey{}, name), func(err error) {
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+
+		if err == nil {
+			tr.spans[name]++
+		}
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &testTracer{spans: make(map[string]int)}
+	session, _ := newTestSession(t, WithTracer(tracer))
+	browser := &testBrowser{}
+
+	// Record the span of each operation.
+	var mu sync.Mutex
+	opSpans := make(map[HttpReqOp]string)
+	doHttpReq := func(ctx context.Context, op HttpReqOp, requestUrl, requestMethod string, requestBody io.Reader) (int, []byte, error) {
+		mu.Lock()
+		opSpans[op], _ = ctx.Value(spanKey{}).(string)
+		mu.Unlock()
+		return browser.doHttpReq(ctx, op, requestUrl, requestMethod, requestBody)
+	}
+
+	if err := session.Generate(context.Background(), testUserAgent, testPageURL, doHttpReq, browser.getCookie, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{
+		SpanGetPage:             1,
+		SpanGetSdkScript:        1,
+		SpanPostSensorData:      2,
+		SpanSolvePixelChallenge: 1,
+	}
+	for name, count := range expected {
+		if v := tracer.spans[name]; v != count {
+			t.Fatalf("expected %d %s spans, got: %d", count, name, v)
+		}
+	}
+
+	expectedOpSpans := map[HttpReqOp]string{
+		OpGetPage:                 SpanGetPage,
+		OpGetSdkScript:            SpanGetSdkScript,
+		OpPostSensorData:          SpanPostSensorData,
+		OpGetPixelChallengeScript: SpanSolvePixelChallenge,
+		OpPostPixelPayload:        SpanSolvePixelChallenge,
+	}
+	for op, name := range expectedOpSpans {
+		if v := opSpans[op]; v != name {
+			t.Fatalf("unexpected span for %s: %q", op, v)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package akamai
+
+import "net/url"
+
+// AbsolutizeURL resolves ref, a URL found in a page, against base, the URL of the page, as browsers do.
+// ref may be absolute (https://cdn.example.com/a), protocol-relative (//cdn.example.com/a), path-absolute
+// (/a) or relative to the path of base (a). The scheme, port and userinfo of base are kept as appropriate.
+//
+// The error returned is non-nil if ref is not a valid URL.
+func AbsolutizeURL(base *url.URL, ref string) (string, error) {
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(r).String(), nil
+}
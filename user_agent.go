@@ -0,0 +1,79 @@
+package akamai
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var (
+	chromeVersionExpr = regexp.MustCompile(`Chrome/(\d+)\.`)
+
+	// ErrUnsupportedUserAgent is an error caused by a user agent that the SolarSystems API cannot generate
+	// payloads for. See GenerateRequest.UserAgent for the current restrictions.
+	ErrUnsupportedUserAgent = errors.New("akamai-sdk-go: unsupported user agent")
+)
+
+// ValidateUserAgent checks that the given user agent is a Google Chrome v109 or v110 user agent, as required by
+// the SolarSystems API.
+//
+// The error returned is non-nil if the user agent is unsupported. In this case, the returned error is
+// ErrUnsupportedUserAgent, joined with an error describing why.
+func ValidateUserAgent(ua string) error {
+	matches := chromeVersionExpr.FindStringSubmatch(ua)
+	if len(matches) < 2 {
+		return errors.Join(ErrUnsupportedUserAgent, errors.New("not a Google Chrome user agent"))
+	}
+
+	major, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return errors.Join(ErrUnsupportedUserAgent, err)
+	}
+	if major != 109 && major != 110 {
+		return errors.Join(ErrUnsupportedUserAgent, fmt.Errorf("unsupported Google Chrome version: %d", major))
+	}
+	return nil
+}
+
+// WithoutUserAgentValidation disables the ValidateUserAgent check made by Session.Generate and
+// Session.GenerateSensorData. This is useful for callers testing user agents the SolarSystems API
+// supports that ValidateUserAgent does not accept yet.
+func WithoutUserAgentValidation() SessionOption {
+	return func(session *Session) {
+		session.skipUserAgentValidation = true
+	}
+}
+
+// WithDefaultUserAgent sets the user agent used by Session.Generate and its variants when their userAgent
+// argument is empty. An explicit userAgent argument always takes precedence.
+//
+// Custom DoHttpReqFunc implementations can read it with Session.UserAgent to set the User-Agent header,
+// so that a single value is used for both the generated payloads and the requests sending them.
+func WithDefaultUserAgent(ua string) SessionOption {
+	return func(session *Session) {
+		session.userAgent = ua
+	}
+}
+
+// UserAgent returns the default user agent of the session set with WithDefaultUserAgent, or an empty
+// string if there is none.
+func (session Session) UserAgent() string {
+	return session.userAgent
+}
+
+// resolveUserAgent returns ua, or the default user agent of the session if ua is empty.
+func (session Session) resolveUserAgent(ua string) string {
+	if ua == "" {
+		return session.userAgent
+	}
+	return ua
+}
+
+// validateUserAgent calls ValidateUserAgent unless the session has user agent validation disabled.
+func (session Session) validateUserAgent(ua string) error {
+	if session.skipUserAgentValidation {
+		return nil
+	}
+	return ValidateUserAgent(ua)
+}
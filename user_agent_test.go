@@ -0,0 +1,54 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateUserAgent(t *testing.T) {
+	for _, ua := range []string{
+		testUserAgent,
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36",
+	} {
+		if err := ValidateUserAgent(ua); err != nil {
+			t.Fatal("err != nil on valid input:", err)
+		}
+	}
+
+	for _, ua := range []string{
+		"",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:109.0) Gecko/20100101 Firefox/110.0",
+	} {
+		if err := ValidateUserAgent(ua); !errors.Is(err, ErrUnsupportedUserAgent) {
+			t.Fatal("expected ErrUnsupportedUserAgent, got:", err)
+		}
+	}
+}
+
+func TestWithDefaultUserAgent(t *testing.T) {
+	const explicitUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36"
+
+	session, api := newTestSession(t, WithDefaultUserAgent(testUserAgent))
+	if v := session.UserAgent(); v != testUserAgent {
+		t.Fatal("unexpected user agent:", v)
+	}
+
+	for _, ua := range []string{"", explicitUserAgent} {
+		browser := &testBrowser{}
+		if err := session.Generate(context.Background(), ua, testPageURL, browser.doHttpReq, browser.getCookie, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(api.sensorRequests) != 2 {
+		t.Fatal("expected 2 sensor requests, got:", len(api.sensorRequests))
+	}
+	if v := api.sensorRequests[0].UserAgent; v != testUserAgent {
+		t.Fatal("expected default user agent, got:", v)
+	}
+	if v := api.sensorRequests[1].UserAgent; v != explicitUserAgent {
+		t.Fatal("expected explicit user agent, got:", v)
+	}
+}
@@ -1,6 +1,10 @@
 package akamai
 
-import "regexp"
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
 
 // Version represents an Akamai Bot Manager web SDK version.
 type Version string
@@ -16,7 +20,41 @@ const (
 	Version2 Version = "2"
 )
 
+// IsKnown reports if v is one of the Version constants.
+func (v Version) IsKnown() bool {
+	return v == Version17 || v == Version175 || v == Version2
+}
+
+// Compare returns -1 if v is older than other, 0 if they are the same version and +1 if v is newer than
+// other. Versions are ordered numerically, so Version175 is newer than Version17. Versions that are not
+// numbers are older than every numeric version and are ordered lexically among themselves.
+func (v Version) Compare(other Version) int {
+	a, aErr := strconv.ParseFloat(string(v), 64)
+	b, bErr := strconv.ParseFloat(string(other), 64)
+	switch {
+	case aErr != nil && bErr != nil:
+		return strings.Compare(string(v), string(other))
+	case aErr != nil:
+		return -1
+	case bErr != nil:
+		return 1
+	}
+
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// AtLeast reports if v is the same as or newer than other, e.g. Version175.AtLeast(Version17) is true.
+func (v Version) AtLeast(other Version) bool {
+	return v.Compare(other) >= 0
+}
+
 var (
+	version17expr  = regexp.MustCompile(`^\s*var _cf\s*=|\bbmak\b`)
 	version175expr = regexp.MustCompile(`^var _acxj`)
 	version2expr   = regexp.MustCompile(`^\(function`)
 )
@@ -31,3 +69,41 @@ func GetSdkVersion(src []byte) Version {
 		return Version17
 	}
 }
+
+// DetectSdkVersion is like GetSdkVersion, but also reports if src matched a known signature.
+// If recognized is false, the returned version is Version17, which is what GetSdkVersion returns for
+// scripts it does not recognize.
+//
+// Version 1.7 scripts are recognized by the `_cf` array declaration they begin with or the `bmak` object
+// they define.
+func DetectSdkVersion(src []byte) (version Version, recognized bool) {
+	if version175expr.Match(src) {
+		return Version175, true
+	} else if version2expr.Match(src) {
+		return Version2, true
+	} else {
+		return Version17, version17expr.Match(src)
+	}
+}
+
+// VersionDetectorFunc detects the Akamai Bot Manager web SDK version from the given JavaScript code src, like
+// DetectSdkVersion. recognized reports if src matched a known signature.
+type VersionDetectorFunc func(src []byte) (version Version, recognized bool)
+
+// WithVersionDetector sets the function Session.Generate detects the web SDK version with, in place of
+// DetectSdkVersion. This allows callers to adapt to changes of the web SDK script before the SDK is updated,
+// e.g. by trying their own signatures first and falling back to DetectSdkVersion. The detected version must
+// be one of the Version constants. If detector is nil, DetectSdkVersion is used, which is the default.
+func WithVersionDetector(detector VersionDetectorFunc) SessionOption {
+	return func(session *Session) {
+		session.versionDetector = detector
+	}
+}
+
+// detectSdkVersion detects the web SDK version from src with the session's VersionDetectorFunc.
+func (session Session) detectSdkVersion(src []byte) (Version, bool) {
+	if session.versionDetector == nil {
+		return DetectSdkVersion(src)
+	}
+	return session.versionDetector(src)
+}
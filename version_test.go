@@ -0,0 +1,118 @@
+package akamai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDetectSdkVersion(t *testing.T) {
+	tests := []struct {
+		src        string
+		version    Version
+		recognized bool
+	}{
+		{`var _cf=_cf||[],bmak=bmak&&bmak.hasOwnProperty("ver")`, Version17, true},
+		{testSdkScript, Version175, true},
+		{`(function(){var a=1;})();`, Version2, true},
+		{`<html><body>Not found</body></html>`, Version17, false},
+		{``, Version17, false},
+	}
+
+	for _, test := range tests {
+		version, recognized := DetectSdkVersion([]byte(test.src))
+		if version != test.version || recognized != test.recognized {
+			t.Fatalf("unexpected result for %q: %s, %t", test.src, version, recognized)
+		}
+		if v := GetSdkVersion([]byte(test.src)); v != version {
+			t.Fatalf("GetSdkVersion mismatch for %q: %s", test.src, v)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b     Version
+		expected int
+	}{
+		{Version17, Version17, 0},
+		{Version17, Version175, -1},
+		{Version175, Version17, 1},
+		{Version175, Version2, -1},
+		{Version2, Version17, 1},
+		{"2.0", Version2, 0},
+		{"1.8", Version175, 1},
+		{"unknown", Version17, -1},
+		{Version17, "unknown", 1},
+		{"a", "b", -1},
+		{"", "", 0},
+	}
+
+	for _, test := range tests {
+		if v := test.a.Compare(test.b); v != test.expected {
+			t.Fatalf("unexpected result comparing %q to %q: %d", test.a, test.b, v)
+		}
+		if v := test.a.AtLeast(test.b); v != (test.expected >= 0) {
+			t.Fatalf("unexpected AtLeast result for %q and %q: %t", test.a, test.b, v)
+		}
+	}
+}
+
+func TestVersionIsKnown(t *testing.T) {
+	for _, v := range []Version{Version17, Version175, Version2} {
+		if !v.IsKnown() {
+			t.Fatal("known version reported as unknown:", v)
+		}
+	}
+	for _, v := range []Version{"", "2.0", "1.8"} {
+		if v.IsKnown() {
+			t.Fatal("unknown version reported as known:", v)
+		}
+	}
+}
+
+func TestWithVersionDetector(t *testing.T) {
+	detector := func(src []byte) (Version, bool) {
+		if string(src) == "var _new=[];" {
+			return Version2, true
+		}
+		return DetectSdkVersion(src)
+	}
+	session, _ := newTestSession(t, WithVersionDetector(detector))
+	browser := &testBrowser{
+		script:      "var _new=[];",
+		cookies:     map[string]string{"bm_sz": "bm_sz-0"},
+		abckCookies: []string{testValidAbck},
+	}
+
+	cfg := DefaultGenerateConfig()
+	cfg.Strict = true
+	result, err := session.GenerateWithConfig(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		cfg,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DetectedVersion != Version2 {
+		t.Fatal("unexpected version:", result.DetectedVersion)
+	}
+
+	session, _ = newTestSession(t, WithVersionDetector(func([]byte) (Version, bool) {
+		return "3", true
+	}))
+	if err = session.Generate(
+		context.Background(),
+		testUserAgent,
+		testPageURL,
+		browser.doHttpReq,
+		browser.getCookie,
+		1,
+	); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatal("expected ErrUnknownVersion, got:", err)
+	}
+}